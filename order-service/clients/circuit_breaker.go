@@ -0,0 +1,125 @@
+package clients
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Metrik breaker dipublikasikan lewat expvar (GET /debug/vars).
+var (
+	breakerStates      = expvar.NewMap("circuit_breaker_state")
+	breakerTransitions = expvar.NewMap("circuit_breaker_transitions")
+	breakerRejected    = expvar.NewMap("circuit_breaker_rejected")
+	httpClientRetries  = expvar.NewMap("http_client_retries")
+)
+
+// circuitBreaker membuka sirkuit setelah failureThreshold kegagalan berturut-turut.
+// Setelah openTimeout, satu request percobaan diizinkan (half-open); jika
+// berhasil sirkuit ditutup, jika gagal sirkuit dibuka lagi.
+type circuitBreaker struct {
+	mu               sync.Mutex
+	name             string
+	state            BreakerState
+	failures         int
+	openedAt         time.Time
+	probing          bool
+	failureThreshold int
+	openTimeout      time.Duration
+	now              func() time.Time
+}
+
+func newCircuitBreaker(name string, failureThreshold int, openTimeout time.Duration) *circuitBreaker {
+	b := &circuitBreaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              time.Now,
+	}
+	b.setState(BreakerClosed)
+
+	return b
+}
+
+func (b *circuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// allow memberi tahu apakah request boleh dikirim.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen {
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			breakerRejected.Add(b.name, 1)
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+	}
+
+	if b.state == BreakerHalfOpen {
+		if b.probing {
+			breakerRejected.Add(b.name, 1)
+			return false
+		}
+		b.probing = true
+	}
+
+	return true
+}
+
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if success {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.setState(BreakerClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.failureThreshold {
+		b.openedAt = b.now()
+		if b.state != BreakerOpen {
+			b.setState(BreakerOpen)
+		}
+	}
+}
+
+// release melepaskan izin dari allow tanpa mencatat hasil, untuk request
+// yang dibatalkan pemanggil sehingga tidak menggambarkan kondisi host.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *circuitBreaker) setState(state BreakerState) {
+	b.state = state
+
+	value := new(expvar.String)
+	value.Set(string(state))
+	breakerStates.Set(b.name, value)
+	breakerTransitions.Add(b.name+":"+string(state), 1)
+}
@@ -0,0 +1,7 @@
+package clients
+
+import "net/http"
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
@@ -0,0 +1,115 @@
+package clients
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type ResilientClientConfig struct {
+	MaxAttempts      int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+var DefaultResilientClientConfig = ResilientClientConfig{
+	MaxAttempts:      3,
+	BaseDelay:        100 * time.Millisecond,
+	MaxDelay:         time.Second,
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+}
+
+type resilientHTTPClient struct {
+	client   HTTPClient
+	config   ResilientClientConfig
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// NewResilientHTTPClient membungkus client dengan retry (exponential backoff
+// + full jitter) dan circuit breaker per host.
+func NewResilientHTTPClient(client HTTPClient, config ResilientClientConfig) HTTPClient {
+	return &resilientHTTPClient{
+		client:   client,
+		config:   config,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+// Do mengirim request. Hanya GET dan HEAD yang di-retry; error jaringan dan
+// status 5xx dihitung sebagai kegagalan, kecuali jika context request sudah
+// dibatalkan pemanggil. Ketika sirkuit terbuka, Do langsung mengembalikan
+// ErrCircuitOpen tanpa menghubungi host.
+func (c *resilientHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	breaker := c.breaker(req.URL.Host)
+
+	attempts := c.config.MaxAttempts
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if !breaker.allow() {
+			return nil, fmt.Errorf("%s: %w", req.URL.Host, ErrCircuitOpen)
+		}
+
+		resp, err := c.client.Do(req)
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			// Cancelled or timed out by the caller, not a sign of an unhealthy host
+			breaker.release()
+			if err != nil {
+				return nil, ctxErr
+			}
+			return resp, nil
+		}
+
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		breaker.record(!failed)
+
+		if !failed || attempt >= attempts {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		httpClientRetries.Add(req.URL.Host, 1)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+func (c *resilientHTTPClient) breaker(host string) *circuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[host]
+	if !ok {
+		b = newCircuitBreaker(host, c.config.FailureThreshold, c.config.OpenTimeout)
+		c.breakers[host] = b
+	}
+
+	return b
+}
+
+// backoff mengembalikan delay acak antara 0 dan min(MaxDelay, BaseDelay*2^(attempt-1)).
+func (c *resilientHTTPClient) backoff(attempt int) time.Duration {
+	delay := c.config.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > c.config.MaxDelay {
+		delay = c.config.MaxDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
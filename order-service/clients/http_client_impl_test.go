@@ -0,0 +1,168 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResult struct {
+	resp *http.Response
+	err  error
+}
+
+// fakeHTTPClient mengembalikan hasil secara berurutan dan menghitung jumlah panggilan.
+type fakeHTTPClient struct {
+	results []fakeResult
+	calls   int
+	onDo    func()
+}
+
+func (f *fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	result := f.results[f.calls]
+	f.calls++
+	if f.onDo != nil {
+		f.onDo()
+	}
+
+	return result.resp, result.err
+}
+
+var testConfig = ResilientClientConfig{
+	MaxAttempts:      3,
+	BaseDelay:        time.Millisecond,
+	MaxDelay:         time.Millisecond,
+	FailureThreshold: 2,
+	OpenTimeout:      time.Minute,
+}
+
+func newResponse(status int) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(`{}`))}
+}
+
+func TestResilientHTTPClient_Do(t *testing.T) {
+	t.Run("should retry server errors until a request succeeds", func(t *testing.T) {
+		fake := &fakeHTTPClient{results: []fakeResult{
+			{err: errors.New("connection refused")},
+			{resp: newResponse(http.StatusServiceUnavailable)},
+			{resp: newResponse(http.StatusOK)},
+		}}
+		c := NewResilientHTTPClient(fake, ResilientClientConfig{
+			MaxAttempts:      3,
+			BaseDelay:        time.Millisecond,
+			MaxDelay:         time.Millisecond,
+			FailureThreshold: 5,
+			OpenTimeout:      time.Minute,
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "http://product-service/products/1", nil)
+		resp, err := c.Do(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 3, fake.calls)
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		fake := &fakeHTTPClient{results: []fakeResult{{resp: newResponse(http.StatusNotFound)}}}
+		c := NewResilientHTTPClient(fake, testConfig)
+
+		req, _ := http.NewRequest(http.MethodGet, "http://product-service/products/1", nil)
+		resp, err := c.Do(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, 1, fake.calls)
+	})
+
+	t.Run("should fail fast once the breaker is open", func(t *testing.T) {
+		fake := &fakeHTTPClient{results: []fakeResult{{err: errors.New("timeout")}, {err: errors.New("timeout")}}}
+		c := NewResilientHTTPClient(fake, testConfig).(*resilientHTTPClient)
+
+		req, _ := http.NewRequest(http.MethodGet, "http://product-service/products/1", nil)
+		_, err := c.Do(req)
+
+		// Expect the breaker to open after two failures and skip the third attempt
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, BreakerOpen, c.breaker("product-service").State())
+
+		_, err = c.Do(req)
+
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 2, fake.calls)
+	})
+
+	t.Run("should stop retrying when the request context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		fake := &fakeHTTPClient{results: []fakeResult{{err: errors.New("connection refused")}}, onDo: cancel}
+		c := NewResilientHTTPClient(fake, ResilientClientConfig{
+			MaxAttempts:      3,
+			BaseDelay:        time.Minute,
+			MaxDelay:         time.Minute,
+			FailureThreshold: 5,
+			OpenTimeout:      time.Minute,
+		})
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://product-service/products/1", nil)
+		_, err := c.Do(req)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("should not count requests cancelled by the caller as failures", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		fake := &fakeHTTPClient{results: []fakeResult{{err: context.Canceled}, {err: context.Canceled}}}
+		c := NewResilientHTTPClient(fake, testConfig).(*resilientHTTPClient)
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://product-service/products/1", nil)
+		c.Do(req)
+		c.Do(req)
+
+		assert.Equal(t, BreakerClosed, c.breaker("product-service").State())
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("should allow a single probe after the open timeout and close on success", func(t *testing.T) {
+		now := time.Now()
+		b := newCircuitBreaker("test-host", 1, time.Second)
+		b.now = func() time.Time { return now }
+
+		b.record(false)
+		assert.Equal(t, BreakerOpen, b.State())
+		assert.False(t, b.allow())
+
+		now = now.Add(2 * time.Second)
+		assert.True(t, b.allow())
+		assert.Equal(t, BreakerHalfOpen, b.State())
+		assert.False(t, b.allow())
+
+		b.record(true)
+		assert.Equal(t, BreakerClosed, b.State())
+		assert.True(t, b.allow())
+	})
+
+	t.Run("should reopen when the probe fails", func(t *testing.T) {
+		now := time.Now()
+		b := newCircuitBreaker("test-host", 3, time.Second)
+		b.now = func() time.Time { return now }
+
+		b.record(false)
+		b.record(false)
+		b.record(false)
+		now = now.Add(2 * time.Second)
+		assert.True(t, b.allow())
+
+		b.record(false)
+
+		assert.Equal(t, BreakerOpen, b.State())
+		assert.False(t, b.allow())
+	})
+}
@@ -0,0 +1,16 @@
+package clients
+
+import "context"
+
+// ProductCatalog adalah read model produk di Redis yang diisi dari event
+// product.created/updated/deleted. Produk yang belum ada di katalog diambil
+// dari product-service lewat HTTP. Qty di katalog bisa tertinggal karena
+// perubahan stok tidak dikirim sebagai event, sehingga hanya harga yang
+// boleh dipercaya.
+type ProductCatalog interface {
+	ProductClient
+	// Save menyimpan produk kecuali katalog sudah punya versi yang lebih baru.
+	Save(ctx context.Context, product Product) error
+	// Remove menandai produk sebagai terhapus pada versi tersebut.
+	Remove(ctx context.Context, id uint, version int64) error
+}
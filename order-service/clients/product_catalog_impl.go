@@ -0,0 +1,155 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"order-service/database"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	catalogTTL = 24 * time.Hour
+
+	// product-service belum mem-publish semua perubahan produk, jadi hasil
+	// fallback HTTP hanya di-cache sebentar agar perubahan harga cepat terbaca.
+	catalogFallbackTTL = time.Minute
+)
+
+// catalogEntry adalah isi key catalog:products:<id>. Produk yang dihapus
+// disimpan sebagai tombstone agar event lama atau fallback HTTP tidak
+// menghidupkannya lagi.
+type catalogEntry struct {
+	Product Product `json:"product"`
+	Deleted bool    `json:"deleted,omitempty"`
+}
+
+type productCatalog struct {
+	cache    database.CacheService
+	fallback ProductClient
+}
+
+func NewProductCatalog(cache database.CacheService, fallback ProductClient) ProductCatalog {
+	return &productCatalog{
+		cache:    cache,
+		fallback: fallback,
+	}
+}
+
+func catalogKey(id uint) string {
+	return "catalog:products:" + strconv.Itoa(int(id))
+}
+
+func (c *productCatalog) GetProduct(ctx context.Context, id uint) (Product, error) {
+	entry, ok := c.load(ctx, id)
+	if ok {
+		if entry.Deleted {
+			return Product{}, fmt.Errorf("%w: product %d", ErrProductNotFound, id)
+		}
+		return entry.Product, nil
+	}
+
+	product, err := c.fallback.GetProduct(ctx, id)
+	if err != nil {
+		return Product{}, err
+	}
+
+	c.fill(ctx, product)
+
+	return product, nil
+}
+
+func (c *productCatalog) GetProducts(ctx context.Context, ids []uint) (map[uint]Product, error) {
+	products := make(map[uint]Product, len(ids))
+	var misses []uint
+
+	for _, id := range ids {
+		entry, ok := c.load(ctx, id)
+		switch {
+		case !ok:
+			misses = append(misses, id)
+		case !entry.Deleted:
+			products[id] = entry.Product
+		}
+	}
+
+	if len(misses) == 0 {
+		return products, nil
+	}
+
+	fetched, err := c.fallback.GetProducts(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, product := range fetched {
+		c.fill(ctx, product)
+		products[id] = product
+	}
+
+	return products, nil
+}
+
+func (c *productCatalog) Save(ctx context.Context, product Product) error {
+	return c.store(ctx, catalogEntry{Product: product})
+}
+
+func (c *productCatalog) Remove(ctx context.Context, id uint, version int64) error {
+	return c.store(ctx, catalogEntry{Product: Product{ID: id, Version: version}, Deleted: true})
+}
+
+// store menulis entry dari event. Event tanpa version (0) selalu ditulis;
+// event yang lebih lama dari isi katalog diabaikan.
+func (c *productCatalog) store(ctx context.Context, entry catalogEntry) error {
+	if entry.Product.Version > 0 {
+		current, ok := c.load(ctx, entry.Product.ID)
+		if ok && current.Product.Version > entry.Product.Version {
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return c.cache.SetWithTTL(ctx, catalogKey(entry.Product.ID), string(data), catalogTTL)
+}
+
+// fill menyimpan hasil fallback HTTP selama catalogFallbackTTL, hanya jika
+// key belum ada, sehingga tidak menimpa data yang baru saja ditulis oleh event.
+func (c *productCatalog) fill(ctx context.Context, product Product) {
+	data, err := json.Marshal(catalogEntry{Product: product})
+	if err != nil {
+		return
+	}
+
+	if _, err := c.cache.SetNX(ctx, catalogKey(product.ID), string(data), catalogFallbackTTL); err != nil {
+		log.Printf("Failed to cache product ID %d: %v", product.ID, err)
+	}
+}
+
+// load membaca entry katalog. Error Redis diperlakukan sebagai miss agar
+// order tetap bisa diproses lewat fallback HTTP.
+func (c *productCatalog) load(ctx context.Context, id uint) (catalogEntry, bool) {
+	data, err := c.cache.Get(ctx, catalogKey(id))
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Failed to read product ID %d from catalog: %v", id, err)
+		}
+		return catalogEntry{}, false
+	}
+
+	var entry catalogEntry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		log.Printf("Failed to decode product ID %d from catalog: %v", id, err)
+		return catalogEntry{}, false
+	}
+
+	return entry, true
+}
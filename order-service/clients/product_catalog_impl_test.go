@@ -0,0 +1,175 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCache adalah CacheService in-memory untuk test katalog.
+type fakeCache struct {
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (c *fakeCache) Get(ctx context.Context, key string) (string, error) {
+	value, ok := c.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (c *fakeCache) SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.values[key] = value
+	c.ttls[key] = ttl
+	return nil
+}
+
+func (c *fakeCache) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+	c.values[key] = value
+	c.ttls[key] = ttl
+	return true, nil
+}
+
+func (c *fakeCache) Del(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(c.values, key)
+	}
+	return nil
+}
+
+func (c *fakeCache) Close() error { return nil }
+
+// fakeProductClient mencatat ID yang diminta ke fallback.
+type fakeProductClient struct {
+	products  map[uint]Product
+	err       error
+	requested []uint
+}
+
+func (f *fakeProductClient) GetProduct(ctx context.Context, id uint) (Product, error) {
+	f.requested = append(f.requested, id)
+	if f.err != nil {
+		return Product{}, f.err
+	}
+
+	product, ok := f.products[id]
+	if !ok {
+		return Product{}, ErrProductNotFound
+	}
+	return product, nil
+}
+
+func (f *fakeProductClient) GetProducts(ctx context.Context, ids []uint) (map[uint]Product, error) {
+	f.requested = append(f.requested, ids...)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	products := map[uint]Product{}
+	for _, id := range ids {
+		if product, ok := f.products[id]; ok {
+			products[id] = product
+		}
+	}
+	return products, nil
+}
+
+func TestProductCatalog_GetProducts(t *testing.T) {
+	t.Run("should serve cached products and fetch only misses over HTTP", func(t *testing.T) {
+		fallback := &fakeProductClient{products: map[uint]Product{2: {ID: 2, Price: 500, Qty: 3}}}
+		c := NewProductCatalog(newFakeCache(), fallback)
+
+		assert.NoError(t, c.Save(context.Background(), Product{ID: 1, Price: 1500, Qty: 10, Version: 1}))
+
+		products, err := c.GetProducts(context.Background(), []uint{1, 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[uint]Product{1: {ID: 1, Price: 1500, Qty: 10, Version: 1}, 2: {ID: 2, Price: 500, Qty: 3}}, products)
+		assert.Equal(t, []uint{2}, fallback.requested)
+
+		// Expect the fetched product to be cached for the next call
+		_, err = c.GetProducts(context.Background(), []uint{1, 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{2}, fallback.requested)
+	})
+
+	t.Run("should treat deleted products as not found without calling HTTP", func(t *testing.T) {
+		fallback := &fakeProductClient{products: map[uint]Product{1: {ID: 1, Price: 1500}}}
+		c := NewProductCatalog(newFakeCache(), fallback)
+
+		assert.NoError(t, c.Remove(context.Background(), 1, 2))
+
+		products, err := c.GetProducts(context.Background(), []uint{1})
+		assert.NoError(t, err)
+		assert.Empty(t, products)
+
+		_, err = c.GetProduct(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+
+		assert.Empty(t, fallback.requested)
+	})
+
+	t.Run("should return fallback errors on a miss", func(t *testing.T) {
+		fallback := &fakeProductClient{err: ErrProductServiceUnavailable}
+		c := NewProductCatalog(newFakeCache(), fallback)
+
+		_, err := c.GetProducts(context.Background(), []uint{1})
+
+		assert.True(t, errors.Is(err, ErrProductServiceUnavailable))
+	})
+}
+
+func TestProductCatalog_Save(t *testing.T) {
+	t.Run("should ignore events older than the cached version", func(t *testing.T) {
+		c := NewProductCatalog(newFakeCache(), &fakeProductClient{})
+
+		assert.NoError(t, c.Save(context.Background(), Product{ID: 1, Price: 2000, Version: 3}))
+		assert.NoError(t, c.Save(context.Background(), Product{ID: 1, Price: 1500, Version: 2}))
+		assert.NoError(t, c.Remove(context.Background(), 1, 1))
+
+		product, err := c.GetProduct(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2000.0, product.Price)
+	})
+
+	t.Run("should not let an HTTP fallback overwrite event data", func(t *testing.T) {
+		cache := newFakeCache()
+		c := NewProductCatalog(cache, &fakeProductClient{}).(*productCatalog)
+
+		assert.NoError(t, c.Save(context.Background(), Product{ID: 1, Price: 2000, Version: 3}))
+		c.fill(context.Background(), Product{ID: 1, Price: 1500})
+
+		product, err := c.GetProduct(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2000.0, product.Price)
+	})
+
+	t.Run("should cache HTTP fallbacks only briefly", func(t *testing.T) {
+		cache := newFakeCache()
+		c := NewProductCatalog(cache, &fakeProductClient{products: map[uint]Product{1: {ID: 1, Price: 1500}}})
+
+		_, err := c.GetProducts(context.Background(), []uint{1})
+		assert.NoError(t, err)
+		assert.Equal(t, catalogFallbackTTL, cache.ttls[catalogKey(1)])
+
+		// Expect a later event to replace the fallback entry with the long TTL
+		assert.NoError(t, c.Save(context.Background(), Product{ID: 1, Price: 2000, Version: 1}))
+		assert.Equal(t, catalogTTL, cache.ttls[catalogKey(1)])
+	})
+}
@@ -0,0 +1,29 @@
+package clients
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrProductNotFound           = errors.New("product not found")
+	ErrProductServiceUnavailable = errors.New("product service unavailable")
+	ErrInvalidProductResponse    = errors.New("invalid product response")
+)
+
+type Product struct {
+	ID      uint    `json:"id"`
+	Name    string  `json:"name"`
+	Price   float64 `json:"price"`
+	Qty     int     `json:"qty"`
+	Version int64   `json:"version,omitempty"`
+}
+
+// ProductClient membaca data produk dari product-service.
+type ProductClient interface {
+	// GetProduct mengembalikan ErrProductNotFound jika produk tidak ada.
+	GetProduct(ctx context.Context, id uint) (Product, error)
+	// GetProducts mengambil beberapa produk sekaligus. Produk yang tidak ada
+	// tidak dimasukkan ke map hasil.
+	GetProducts(ctx context.Context, ids []uint) (map[uint]Product, error)
+}
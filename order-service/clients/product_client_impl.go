@@ -0,0 +1,112 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const maxConcurrentProductRequests = 8
+
+type productResponse struct {
+	Data *Product `json:"data"`
+}
+
+type productClient struct {
+	baseURL    string
+	httpClient HTTPClient
+}
+
+func NewProductClient(baseURL string, httpClient HTTPClient) ProductClient {
+	return &productClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+func (c *productClient) GetProduct(ctx context.Context, id uint) (Product, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/products/%d", c.baseURL, id), nil)
+	if err != nil {
+		return Product{}, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Product{}, fmt.Errorf("%w: %v", ErrProductServiceUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return Product{}, fmt.Errorf("%w: product %d", ErrProductNotFound, id)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return Product{}, fmt.Errorf("%w: status %d", ErrProductServiceUnavailable, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return Product{}, fmt.Errorf("%w: status %d", ErrInvalidProductResponse, resp.StatusCode)
+	}
+
+	var productResp productResponse
+	if err := json.NewDecoder(resp.Body).Decode(&productResp); err != nil {
+		return Product{}, fmt.Errorf("%w: %v", ErrInvalidProductResponse, err)
+	}
+
+	// product-service menjawab 200 dengan data null untuk ID yang tidak ada.
+	if productResp.Data == nil {
+		return Product{}, fmt.Errorf("%w: product %d", ErrProductNotFound, id)
+	}
+
+	return *productResp.Data, nil
+}
+
+// GetProducts memanggil GetProduct secara paralel karena product-service
+// belum punya endpoint batch.
+func (c *productClient) GetProducts(ctx context.Context, ids []uint) (map[uint]Product, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		products = make(map[uint]Product, len(ids))
+		sem      = make(chan struct{}, maxConcurrentProductRequests)
+	)
+
+	for _, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(id uint) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			product, err := c.GetProduct(ctx, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case errors.Is(err, ErrProductNotFound):
+			case err != nil:
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+			default:
+				products[id] = product
+			}
+		}(id)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return products, nil
+}
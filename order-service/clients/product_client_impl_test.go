@@ -0,0 +1,119 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newProductServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/products/1":
+			w.Write([]byte(`{"status":true,"data":{"id":1,"name":"Keyboard","price":1500,"qty":10}}`))
+		case "/products/2":
+			w.Write([]byte(`{"status":true,"data":null}`))
+		case "/products/3":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/products/4":
+			w.Write([]byte(`not-json`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestProductClient_GetProduct(t *testing.T) {
+	server := newProductServer(t)
+	c := NewProductClient(server.URL+"/", server.Client())
+
+	t.Run("should return the product", func(t *testing.T) {
+		product, err := c.GetProduct(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Product{ID: 1, Name: "Keyboard", Price: 1500, Qty: 10}, product)
+	})
+
+	t.Run("should map a missing product to ErrProductNotFound", func(t *testing.T) {
+		_, err := c.GetProduct(context.Background(), 2)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+
+		_, err = c.GetProduct(context.Background(), 99)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+	})
+
+	t.Run("should map server errors to ErrProductServiceUnavailable", func(t *testing.T) {
+		_, err := c.GetProduct(context.Background(), 3)
+
+		assert.ErrorIs(t, err, ErrProductServiceUnavailable)
+	})
+
+	t.Run("should map undecodable body to ErrInvalidProductResponse", func(t *testing.T) {
+		_, err := c.GetProduct(context.Background(), 4)
+
+		assert.ErrorIs(t, err, ErrInvalidProductResponse)
+	})
+}
+
+func TestProductClient_GetProducts(t *testing.T) {
+	server := newProductServer(t)
+	c := NewProductClient(server.URL, server.Client())
+
+	t.Run("should skip products that do not exist", func(t *testing.T) {
+		products, err := c.GetProducts(context.Background(), []uint{1, 2, 99})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[uint]Product{1: {ID: 1, Name: "Keyboard", Price: 1500, Qty: 10}}, products)
+	})
+
+	t.Run("should fail when any product cannot be loaded", func(t *testing.T) {
+		products, err := c.GetProducts(context.Background(), []uint{1, 3})
+
+		assert.Nil(t, products)
+		assert.ErrorIs(t, err, ErrProductServiceUnavailable)
+	})
+}
+
+func TestProductClient_GetProducts_CircuitBreaker(t *testing.T) {
+	t.Run("should keep the breaker closed when one item of a large order fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/products/3" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			// Hold the other items until GetProducts cancels them
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+				w.Write([]byte(`{"status":true,"data":{"id":1,"price":1500,"qty":10}}`))
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		httpClient := NewResilientHTTPClient(server.Client(), ResilientClientConfig{
+			MaxAttempts:      3,
+			BaseDelay:        time.Millisecond,
+			MaxDelay:         time.Millisecond,
+			FailureThreshold: 5,
+			OpenTimeout:      time.Minute,
+		}).(*resilientHTTPClient)
+		c := NewProductClient(server.URL, httpClient)
+
+		_, err := c.GetProducts(context.Background(), []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+
+		assert.ErrorIs(t, err, ErrProductServiceUnavailable)
+
+		// Expect only the attempts for product 3 to count, not the cancelled siblings
+		serverURL, _ := url.Parse(server.URL)
+		assert.Equal(t, BreakerClosed, httpClient.breaker(serverURL.Host).State())
+	})
+}
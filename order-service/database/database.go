@@ -29,8 +29,35 @@ func Connect() (*gorm.DB, error) {
 
 	log.Println("Database connection successfully opened!")
 
-	db.AutoMigrate(&models.Order{})
+	db.AutoMigrate(&models.Order{}, &models.OrderItem{}, &models.OutboxEvent{}, &models.ProcessedMessage{}, &models.FailedOrder{}, &models.OrderStatusHistory{})
+	if err := migrateOrderItems(db); err != nil {
+		log.Fatalf("Failed to migrate order items: %v", err)
+	}
 	log.Println("Database migration completed!")
 
 	return db, nil
 }
+
+// migrateOrderItems memindahkan kolom product_id/qty dari versi lama tabel
+// orders ke order_items, lalu menghapus kolom tersebut.
+func migrateOrderItems(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Order{}, "product_id") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO order_items (order_id, product_id, qty, unit_price, subtotal)
+			SELECT o.id, o.product_id, o.qty, CASE WHEN o.qty > 0 THEN o.total_price / o.qty ELSE 0 END, o.total_price
+			FROM orders o
+			WHERE NOT EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id)`).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Migrator().DropColumn(&models.Order{}, "product_id"); err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropColumn(&models.Order{}, "qty")
+	})
+}
@@ -13,9 +13,11 @@ import (
 
 // CacheService adalah interface untuk fungsionalitas cache.
 type CacheService interface {
-	Get(key string) (string, error)
-	SetWithTTL(key, value string, ttl time.Duration) error
-	Del(keys ...string) error
+	Get(ctx context.Context, key string) (string, error)
+	SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	Del(ctx context.Context, keys ...string) error
+	Close() error
 }
 
 // RedisService adalah implementasi dari CacheService.
@@ -40,16 +42,27 @@ func NewRedisService() CacheService {
 }
 
 // SetWithTTL mengimplementasikan method dari CacheService.
-func (r *RedisService) SetWithTTL(key string, value string, ttl time.Duration) error {
-	return r.client.Set(context.Background(), key, value, ttl).Err()
+func (r *RedisService) SetWithTTL(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetNX mengimplementasikan method dari CacheService. Mengembalikan false
+// jika key sudah ada.
+func (r *RedisService) SetNX(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, ttl).Result()
 }
 
 // Get mengimplementasikan method dari CacheService.
-func (r *RedisService) Get(key string) (string, error) {
-	return r.client.Get(context.Background(), key).Result()
+func (r *RedisService) Get(ctx context.Context, key string) (string, error) {
+	return r.client.Get(ctx, key).Result()
 }
 
 // Del mengimplementasikan method dari CacheService.
-func (r *RedisService) Del(keys ...string) error {
-	return r.client.Del(context.Background(), keys...).Err()
+func (r *RedisService) Del(ctx context.Context, keys ...string) error {
+	return r.client.Del(ctx, keys...).Err()
+}
+
+// Close mengimplementasikan method dari CacheService.
+func (r *RedisService) Close() error {
+	return r.client.Close()
 }
@@ -1,6 +1,14 @@
 package request
 
 type CreateOrderRequest struct {
+	Items []CreateOrderItemRequest `json:"items" validate:"required_without=ProductID,omitnil,min=1,max=50,unique=ProductID,dive"`
+
+	// ProductID dan Qty adalah format lama untuk order dengan satu produk.
+	ProductID uint `json:"product_id" validate:"required_without=Items,excluded_with=Items"`
+	Qty       int  `json:"qty" validate:"required_with=ProductID,omitempty,gt=0"`
+}
+
+type CreateOrderItemRequest struct {
 	ProductID uint `json:"product_id" validate:"required"`
 	Qty       int  `json:"qty" validate:"required,gt=0"`
 }
@@ -0,0 +1,22 @@
+package request
+
+type FindOrdersRequest struct {
+	Limit       int    `query:"limit" validate:"omitempty,gt=0,lte=100"`
+	Offset      int    `query:"offset" validate:"omitempty,gte=0"`
+	Cursor      string `query:"cursor"`
+	Status      string `query:"status" validate:"omitempty,oneof=pending processing awaiting_stock completed failed cancelled refunded"`
+	ProductID   uint   `query:"product_id"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Sort        string `query:"sort" validate:"omitempty,oneof=id -id created_at -created_at"`
+}
+
+type FindFailedOrdersRequest struct {
+	Limit  int `query:"limit" validate:"omitempty,gt=0,lte=100"`
+	Offset int `query:"offset" validate:"omitempty,gte=0"`
+}
+
+type AdminFindOrdersRequest struct {
+	FindOrdersRequest
+	IncludeDeleted bool `query:"include_deleted"`
+}
@@ -1,5 +1,6 @@
 package request
 
 type UpdateOrderRequest struct {
-	Status string `json:"status" validate:"required"`
+	Status string `json:"status" validate:"required,oneof=pending processing awaiting_stock completed failed cancelled refunded"`
+	Reason string `json:"reason" validate:"max=255"`
 }
@@ -1,8 +1,9 @@
 package response
 
 type BaseResponse struct {
-	Status  bool   `json:"status"`
-	Message string `json:"message"`
-	Error   string `json:"error,omitempty"`
-	Data    any    `json:"data"`
-}
\ No newline at end of file
+	Status     bool        `json:"status"`
+	Message    string      `json:"message"`
+	Error      string      `json:"error,omitempty"`
+	Data       any         `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
@@ -0,0 +1,22 @@
+package entities
+
+import "time"
+
+type FailedOrder struct {
+	ID          uint      `json:"id"`
+	OrderID     uint      `json:"order_id"`
+	Reason      string    `json:"reason"`
+	Payload     string    `json:"payload"`
+	ReplayCount int       `json:"replay_count"`
+	ReplayedAt  time.Time `json:"replayed_at,omitempty"`
+	FailedAt    time.Time `json:"failed_at"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+type FailedOrderPage struct {
+	FailedOrders []FailedOrder
+	Total        int64
+	Limit        int
+	Offset       int
+	HasMore      bool
+}
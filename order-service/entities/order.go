@@ -3,11 +3,21 @@ package entities
 import "time"
 
 type Order struct {
-	ID         uint      `json:"id"`
-	ProductID  uint      `json:"product_id"`
-	Qty        int       `json:"qty"`
-	TotalPrice float64   `json:"total_price,omitempty"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at,omitempty"`
-	UpdatedAt  time.Time `json:"updated_at,omitempty"`
+	ID         uint        `json:"id"`
+	Items      []OrderItem `json:"items,omitempty"`
+	TotalPrice float64     `json:"total_price,omitempty"`
+	Status     OrderStatus `json:"status"`
+	CreatedAt  time.Time   `json:"created_at,omitempty"`
+	UpdatedAt  time.Time   `json:"updated_at,omitempty"`
+	DeletedAt  *time.Time  `json:"deleted_at,omitempty"`
+}
+
+// ProductIDs mengembalikan ID produk dari setiap item order.
+func (o Order) ProductIDs() []uint {
+	ids := make([]uint, 0, len(o.Items))
+	for _, item := range o.Items {
+		ids = append(ids, item.ProductID)
+	}
+
+	return ids
 }
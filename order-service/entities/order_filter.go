@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	OrderSortByID        = "id"
+	OrderSortByCreatedAt = "created_at"
+)
+
+// OrderFilter describes which orders GET /orders returns and in which order.
+// When Cursor is set, keyset pagination is used and Offset is ignored.
+type OrderFilter struct {
+	Status      OrderStatus
+	ProductID   uint
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	SortBy      string
+	SortDesc    bool
+	Limit       int
+	Offset      int
+	Cursor      *OrderCursor
+
+	// IncludeDeleted juga mengembalikan order yang sudah di-soft delete.
+	IncludeDeleted bool
+}
+
+// OrderCursor is the position of the last order of the previous page.
+type OrderCursor struct {
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrderPage struct {
+	Orders     []Order
+	Total      int64
+	Limit      int
+	Offset     int
+	HasMore    bool
+	NextCursor string
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode mengubah cursor menjadi token opaque untuk query string.
+func (c OrderCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeOrderCursor(token string) (*OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor OrderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
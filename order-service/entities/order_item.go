@@ -0,0 +1,10 @@
+package entities
+
+type OrderItem struct {
+	ID        uint    `json:"id,omitempty"`
+	OrderID   uint    `json:"order_id,omitempty"`
+	ProductID uint    `json:"product_id"`
+	Qty       int     `json:"qty"`
+	UnitPrice float64 `json:"unit_price,omitempty"`
+	Subtotal  float64 `json:"subtotal,omitempty"`
+}
@@ -0,0 +1,75 @@
+package entities
+
+type OrderStatus string
+
+const (
+	OrderStatusPending       OrderStatus = "pending"
+	OrderStatusProcessing    OrderStatus = "processing"
+	OrderStatusAwaitingStock OrderStatus = "awaiting_stock"
+	OrderStatusCompleted     OrderStatus = "completed"
+	OrderStatusFailed        OrderStatus = "failed"
+	OrderStatusCancelled     OrderStatus = "cancelled"
+	OrderStatusRefunded      OrderStatus = "refunded"
+)
+
+// orderTransitions berisi perpindahan status yang diizinkan. Status yang tidak
+// punya entri adalah status akhir.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:       {OrderStatusProcessing, OrderStatusAwaitingStock, OrderStatusCompleted, OrderStatusFailed, OrderStatusCancelled},
+	OrderStatusProcessing:    {OrderStatusCompleted, OrderStatusFailed},
+	OrderStatusAwaitingStock: {OrderStatusCompleted, OrderStatusFailed, OrderStatusCancelled},
+	OrderStatusCompleted:     {OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusFailed:        {OrderStatusPending}, // replay lewat admin API
+}
+
+// manualOrderTransitions adalah bagian dari orderTransitions yang boleh
+// dilakukan lewat PUT /orders/:id. Perpindahan ke failed tetap menulis event
+// order.failed agar order bisa di-replay. Perpindahan lain punya efek samping
+// (event stok, pelepasan reservasi, atau order request baru), sehingga harus
+// lewat Cancel, saga stok, atau replay admin. pending → processing tidak
+// diizinkan karena consumer hanya memproses order yang masih pending.
+var manualOrderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusFailed},
+	OrderStatusProcessing: {OrderStatusFailed},
+	OrderStatusCompleted:  {OrderStatusRefunded},
+}
+
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusProcessing, OrderStatusAwaitingStock, OrderStatusCompleted,
+		OrderStatusFailed, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+
+	return false
+}
+
+// IsInProgress melaporkan apakah order masih diproses consumer atau saga stok.
+func (s OrderStatus) IsInProgress() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusProcessing, OrderStatusAwaitingStock:
+		return true
+	}
+
+	return false
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return containsStatus(orderTransitions[s], next)
+}
+
+// CanTransitionManuallyTo melaporkan apakah status boleh diubah langsung
+// tanpa efek samping lain.
+func (s OrderStatus) CanTransitionManuallyTo(next OrderStatus) bool {
+	return containsStatus(manualOrderTransitions[s], next)
+}
+
+func containsStatus(statuses []OrderStatus, status OrderStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+type OrderStatusHistory struct {
+	ID        uint        `json:"id"`
+	OrderID   uint        `json:"order_id"`
+	OldStatus OrderStatus `json:"old_status"`
+	NewStatus OrderStatus `json:"new_status"`
+	Reason    string      `json:"reason,omitempty"`
+	Actor     string      `json:"actor"`
+	CreatedAt time.Time   `json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+type OutboxEvent struct {
+	ID            string    `json:"id"`
+	Exchange      string    `json:"exchange"`
+	RoutingKey    string    `json:"routing_key"`
+	PartitionKey  string    `json:"partition_key,omitempty"`
+	ContentType   string    `json:"content_type"`
+	Payload       []byte    `json:"payload"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	SentAt        time.Time `json:"sent_at,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+}
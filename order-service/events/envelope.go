@@ -0,0 +1,188 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidEvent       = errors.New("invalid event")
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+)
+
+// Envelope membungkus setiap event. Field pattern dan data tetap dipakai
+// agar event bisa langsung diproses oleh @EventPattern di NestJS. ID disimpan
+// di event_id karena NestJS memperlakukan packet dengan field id sebagai
+// request yang harus dibalas.
+//
+// payload berisi data dalam bentuk struct, yaitu data yang diberikan ke New
+// atau hasil decode Protobuf. Data dari Protobuf tidak diubah ke JSON kecuali
+// diminta lewat JSON.
+type Envelope struct {
+	ID            string          `json:"event_id,omitempty"`
+	Type          string          `json:"type,omitempty"`
+	Version       int             `json:"version,omitempty"`
+	OccurredAt    time.Time       `json:"occurred_at,omitempty"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	Pattern       string          `json:"pattern"`
+	Data          json.RawMessage `json:"data"`
+
+	payload interface{}
+}
+
+var validate = validator.New()
+
+// New membuat envelope versi terbaru untuk eventType. Jika correlationID
+// kosong, ID event dipakai sebagai awal rantai korelasi.
+func New(eventType string, data interface{}, correlationID string) (Envelope, error) {
+	version, ok := latestVersions[eventType]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: unknown type %q", ErrInvalidEvent, eventType)
+	}
+
+	if err := validate.Struct(data); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %s: %v", ErrInvalidEvent, eventType, err)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal %s data: %w", eventType, err)
+	}
+
+	id := uuid.NewString()
+	if correlationID == "" {
+		correlationID = id
+	}
+
+	return Envelope{
+		ID:            id,
+		Type:          eventType,
+		Version:       version,
+		OccurredAt:    time.Now().UTC(),
+		CorrelationID: correlationID,
+		Pattern:       eventType,
+		Data:          raw,
+		payload:       data,
+	}, nil
+}
+
+// DecodeEnvelope membaca envelope dengan serializer sesuai contentType.
+// Producer NestJS yang hanya mengirim pattern dan data dianggap versi 1.
+func DecodeEnvelope(contentType string, body []byte) (Envelope, error) {
+	serializer, err := SerializerFor(contentType)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	env, err := serializer.Unmarshal(body)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	if env.Type == "" {
+		env.Type = env.Pattern
+	}
+	if env.Version == 0 {
+		env.Version = 1
+	}
+
+	latest, ok := latestVersions[env.Type]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: unknown type %q", ErrInvalidEvent, env.Type)
+	}
+
+	if env.Version < 1 || env.Version > latest {
+		return Envelope{}, fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, env.Type, env.Version)
+	}
+
+	if len(env.Data) == 0 && env.payload == nil {
+		return Envelope{}, fmt.Errorf("%w: %s has no data", ErrInvalidEvent, env.Type)
+	}
+
+	return env, nil
+}
+
+// DecodeData membaca data ke struct payload lalu memvalidasinya. Data yang
+// sudah di-decode oleh serializer Protobuf langsung disalin ke v.
+func (e Envelope) DecodeData(v interface{}) error {
+	if len(e.Data) == 0 && e.payload != nil {
+		if err := copyPayload(e.payload, v); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidEvent, e.Type, err)
+		}
+	} else if err := decodeStrict(e.Data, v); err != nil {
+		return err
+	}
+
+	if err := validate.Struct(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidEvent, e.Type, err)
+	}
+
+	return nil
+}
+
+// JSON mengembalikan envelope dalam bentuk JSON, termasuk event yang diterima
+// sebagai Protobuf.
+func (e Envelope) JSON() ([]byte, error) {
+	if len(e.Data) == 0 && e.payload != nil {
+		raw, err := json.Marshal(e.payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s data: %w", e.Type, err)
+		}
+		e.Data = raw
+	}
+
+	return json.Marshal(e)
+}
+
+// Decode membaca event bertipe eventType beserta datanya.
+func Decode(contentType string, body []byte, eventType string, v interface{}) (Envelope, error) {
+	env, err := DecodeEnvelope(contentType, body)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	if env.Type != eventType {
+		return Envelope{}, fmt.Errorf("%w: expected %s, got %s", ErrInvalidEvent, eventType, env.Type)
+	}
+
+	if err := env.DecodeData(v); err != nil {
+		return Envelope{}, err
+	}
+
+	return env, nil
+}
+
+func decodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	return nil
+}
+
+// copyPayload menyalin payload ke v yang harus berupa pointer ke tipe yang sama.
+func copyPayload(payload, v interface{}) error {
+	src := reflect.ValueOf(payload)
+	if src.Kind() != reflect.Pointer {
+		src = reflect.New(src.Type())
+		src.Elem().Set(reflect.ValueOf(payload))
+	}
+
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Pointer || dst.IsNil() || dst.Type() != src.Type() {
+		return fmt.Errorf("cannot decode %T into %T", payload, v)
+	}
+
+	dst.Elem().Set(src.Elem())
+	return nil
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should wrap data in a versioned envelope usable by NestJS", func(t *testing.T) {
+		env, err := New(TypeOrderCreated, OrderCreated{OrderID: 10, ProductID: 123, Qty: 2}, "corr-1")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, env.ID)
+		assert.Equal(t, TypeOrderCreated, env.Type)
+		assert.Equal(t, TypeOrderCreated, env.Pattern)
+		assert.Equal(t, 1, env.Version)
+		assert.Equal(t, "corr-1", env.CorrelationID)
+		assert.False(t, env.OccurredAt.IsZero())
+		assert.JSONEq(t, `{"orderID":10,"productID":123,"qty":2}`, string(env.Data))
+	})
+
+	t.Run("should start a correlation chain when none is given", func(t *testing.T) {
+		env, err := New(TypeStockReserved, StockReply{OrderID: 10}, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, env.ID, env.CorrelationID)
+	})
+
+	t.Run("should reject invalid data", func(t *testing.T) {
+		_, err := New(TypeOrderCreatedRequest, OrderCreatedRequest{OrderID: 10}, "")
+
+		assert.ErrorIs(t, err, ErrInvalidEvent)
+	})
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("should decode an event created by New", func(t *testing.T) {
+		env, _ := New(TypeOrderCreatedRequest, OrderCreatedRequest{OrderID: 10, Items: []OrderItem{{ProductID: 123, Qty: 2}}}, "corr-1")
+		body, _ := json.Marshal(env)
+
+		var data OrderCreatedRequest
+		decoded, err := Decode(ContentTypeJSON, body, TypeOrderCreatedRequest, &data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, env.ID, decoded.ID)
+		assert.Equal(t, "corr-1", decoded.CorrelationID)
+		assert.Equal(t, OrderCreatedRequest{OrderID: 10, Items: []OrderItem{{ProductID: 123, Qty: 2}}}, data)
+	})
+
+	t.Run("should treat NestJS events without metadata as version 1", func(t *testing.T) {
+		var data StockReply
+		env, err := Decode("", []byte(`{"pattern":"stock.rejected","data":{"orderID":10,"reason":"Out of stock"}}`), TypeStockRejected, &data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, env.Version)
+		assert.Equal(t, StockReply{OrderID: 10, Reason: "Out of stock"}, data)
+	})
+
+	t.Run("should reject unknown versions", func(t *testing.T) {
+		var data StockReply
+		_, err := Decode(ContentTypeJSON, []byte(`{"version":2,"pattern":"stock.reserved","data":{"orderID":10}}`), TypeStockReserved, &data)
+
+		assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	})
+
+	t.Run("should reject unknown fields, missing fields and unexpected types", func(t *testing.T) {
+		bodies := []string{
+			`{"orderID":10}`,
+			`{"pattern":"stock.reserved","data":{}}`,
+			`{"pattern":"stock.reserved","data":{"orderID":10,"extra":1}}`,
+			`{"pattern":"stock.rejected","data":{"orderID":10}}`,
+			`{"pattern":"stock.unknown","data":{"orderID":10}}`,
+		}
+
+		for _, body := range bodies {
+			var data StockReply
+			_, err := Decode(ContentTypeJSON, []byte(body), TypeStockReserved, &data)
+
+			assert.ErrorIs(t, err, ErrInvalidEvent, body)
+		}
+	})
+}
+
+func TestPartitionKey(t *testing.T) {
+	assert.Equal(t, "123", PartitionKey(OrderCreated{OrderID: 10, ProductID: 123, Qty: 2}))
+	assert.Equal(t, "123", PartitionKey(OrderCancelled{OrderID: 10, ProductID: 123, Qty: 2}))
+	assert.Equal(t, "10", PartitionKey(OrderFailed{OrderID: 10, Reason: "Out of stock"}))
+	assert.Equal(t, "", PartitionKey(struct{}{}))
+}
@@ -0,0 +1,225 @@
+package events
+
+//go:generate protoc --proto_path=eventspb --go_out=eventspb --go_opt=paths=source_relative events.proto
+
+import (
+	"encoding/json"
+	"fmt"
+	"order-service/events/eventspb"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// protoCodec mengubah data event ke message yang di-generate dari
+// events.proto dan sebaliknya.
+type protoCodec interface {
+	marshal(env Envelope) ([]byte, error)
+	unmarshal(b []byte) (interface{}, error)
+}
+
+// protoMapping menghubungkan struct data T dengan message M.
+type protoMapping[T any, M any, PM interface {
+	*M
+	proto.Message
+}] struct {
+	toProto   func(T) PM
+	fromProto func(PM) T
+}
+
+func (m protoMapping[T, M, PM]) marshal(env Envelope) ([]byte, error) {
+	var data T
+	switch payload := env.payload.(type) {
+	case T:
+		data = payload
+	case *T:
+		data = *payload
+	default:
+		if err := json.Unmarshal(env.Data, &data); err != nil {
+			return nil, err
+		}
+	}
+
+	return proto.Marshal(m.toProto(data))
+}
+
+func (m protoMapping[T, M, PM]) unmarshal(b []byte) (interface{}, error) {
+	msg := PM(new(M))
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return nil, err
+	}
+
+	data := m.fromProto(msg)
+	return &data, nil
+}
+
+var (
+	orderCreatedRequestCodec = protoMapping[OrderCreatedRequest, eventspb.OrderCreatedRequest, *eventspb.OrderCreatedRequest]{
+		toProto: func(e OrderCreatedRequest) *eventspb.OrderCreatedRequest {
+			return &eventspb.OrderCreatedRequest{OrderId: uint64(e.OrderID), Items: itemsToProto(e.Items)}
+		},
+		fromProto: func(m *eventspb.OrderCreatedRequest) OrderCreatedRequest {
+			return OrderCreatedRequest{OrderID: uint(m.GetOrderId()), Items: itemsFromProto(m.GetItems())}
+		},
+	}
+	orderCreatedCodec = protoMapping[OrderCreated, eventspb.OrderCreated, *eventspb.OrderCreated]{
+		toProto: func(e OrderCreated) *eventspb.OrderCreated {
+			return &eventspb.OrderCreated{OrderId: uint64(e.OrderID), ProductId: uint64(e.ProductID), Qty: int64(e.Qty), StockReserved: e.StockReserved}
+		},
+		fromProto: func(m *eventspb.OrderCreated) OrderCreated {
+			return OrderCreated{OrderID: uint(m.GetOrderId()), ProductID: uint(m.GetProductId()), Qty: int(m.GetQty()), StockReserved: m.GetStockReserved()}
+		},
+	}
+	orderFailedCodec = protoMapping[OrderFailed, eventspb.OrderFailed, *eventspb.OrderFailed]{
+		toProto: func(e OrderFailed) *eventspb.OrderFailed {
+			return &eventspb.OrderFailed{OrderId: uint64(e.OrderID), Items: itemsToProto(e.Items), Reason: e.Reason}
+		},
+		fromProto: func(m *eventspb.OrderFailed) OrderFailed {
+			return OrderFailed{OrderID: uint(m.GetOrderId()), Items: itemsFromProto(m.GetItems()), Reason: m.GetReason()}
+		},
+	}
+	orderCancelledCodec = protoMapping[OrderCancelled, eventspb.OrderCancelled, *eventspb.OrderCancelled]{
+		toProto: func(e OrderCancelled) *eventspb.OrderCancelled {
+			return &eventspb.OrderCancelled{OrderId: uint64(e.OrderID), ProductId: uint64(e.ProductID), Qty: int64(e.Qty), Restock: e.Restock}
+		},
+		fromProto: func(m *eventspb.OrderCancelled) OrderCancelled {
+			return OrderCancelled{OrderID: uint(m.GetOrderId()), ProductID: uint(m.GetProductId()), Qty: int(m.GetQty()), Restock: m.GetRestock()}
+		},
+	}
+	stockRequestCodec = protoMapping[StockRequest, eventspb.StockRequest, *eventspb.StockRequest]{
+		toProto: func(e StockRequest) *eventspb.StockRequest {
+			return &eventspb.StockRequest{OrderId: uint64(e.OrderID), Items: itemsToProto(e.Items)}
+		},
+		fromProto: func(m *eventspb.StockRequest) StockRequest {
+			return StockRequest{OrderID: uint(m.GetOrderId()), Items: itemsFromProto(m.GetItems())}
+		},
+	}
+	stockReplyCodec = protoMapping[StockReply, eventspb.StockReply, *eventspb.StockReply]{
+		toProto: func(e StockReply) *eventspb.StockReply {
+			return &eventspb.StockReply{OrderId: uint64(e.OrderID), Reason: e.Reason}
+		},
+		fromProto: func(m *eventspb.StockReply) StockReply {
+			return StockReply{OrderID: uint(m.GetOrderId()), Reason: m.GetReason()}
+		},
+	}
+)
+
+// protoCodecs berisi tipe event yang bisa dikirim sebagai Protobuf.
+var protoCodecs = map[string]protoCodec{
+	TypeOrderCreatedRequest: orderCreatedRequestCodec,
+	TypeOrderCreated:        orderCreatedCodec,
+	TypeOrderFailed:         orderFailedCodec,
+	TypeOrderCancelled:      orderCancelledCodec,
+	TypeStockReserve:        stockRequestCodec,
+	TypeStockRelease:        stockRequestCodec,
+	TypeStockReserved:       stockReplyCodec,
+	TypeStockRejected:       stockReplyCodec,
+}
+
+// protobufSerializer meng-encode message Envelope di events.proto. Saat
+// decode, data langsung dibaca ke struct sesuai tipe event tanpa melewati
+// JSON; Envelope.DecodeData hanya menyalin dan memvalidasinya.
+type protobufSerializer struct{}
+
+func (protobufSerializer) ContentType() string {
+	return ContentTypeProtobuf
+}
+
+func (protobufSerializer) Supports(eventType string) bool {
+	_, ok := protoCodecs[eventType]
+	return ok
+}
+
+func (protobufSerializer) Marshal(env Envelope) ([]byte, error) {
+	codec, ok := protoCodecs[env.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no protobuf schema", ErrUnsupportedContentType, env.Type)
+	}
+
+	data, err := codec.marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", env.Type, err)
+	}
+
+	msg := &eventspb.Envelope{
+		EventId:       env.ID,
+		Type:          env.Type,
+		Version:       int32(env.Version),
+		CorrelationId: env.CorrelationID,
+		Data:          data,
+	}
+	if !env.OccurredAt.IsZero() {
+		msg.OccurredAt = timestamppb.New(env.OccurredAt)
+	}
+
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", env.Type, err)
+	}
+
+	return body, nil
+}
+
+func (protobufSerializer) Unmarshal(body []byte) (Envelope, error) {
+	var msg eventspb.Envelope
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	env := Envelope{
+		ID:            msg.GetEventId(),
+		Type:          msg.GetType(),
+		Version:       int(msg.GetVersion()),
+		CorrelationID: msg.GetCorrelationId(),
+		Pattern:       msg.GetType(),
+	}
+	if msg.OccurredAt != nil {
+		if err := msg.OccurredAt.CheckValid(); err != nil {
+			return Envelope{}, fmt.Errorf("%w: occurred_at: %v", ErrInvalidEvent, err)
+		}
+		env.OccurredAt = msg.OccurredAt.AsTime()
+	}
+
+	codec, ok := protoCodecs[env.Type]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s has no protobuf schema", ErrInvalidEvent, env.Type)
+	}
+
+	if len(msg.GetData()) == 0 {
+		return env, nil
+	}
+
+	payload, err := codec.unmarshal(msg.GetData())
+	if err != nil {
+		return Envelope{}, fmt.Errorf("%w: %s: %v", ErrInvalidEvent, env.Type, err)
+	}
+	env.payload = payload
+
+	return env, nil
+}
+
+func itemsToProto(items []OrderItem) []*eventspb.OrderItem {
+	if items == nil {
+		return nil
+	}
+
+	msgs := make([]*eventspb.OrderItem, 0, len(items))
+	for _, item := range items {
+		msgs = append(msgs, &eventspb.OrderItem{ProductId: uint64(item.ProductID), Qty: int64(item.Qty)})
+	}
+
+	return msgs
+}
+
+func itemsFromProto(msgs []*eventspb.OrderItem) []OrderItem {
+	if msgs == nil {
+		return nil
+	}
+
+	items := make([]OrderItem, 0, len(msgs))
+	for _, msg := range msgs {
+		items = append(items, OrderItem{ProductID: uint(msg.GetProductId()), Qty: int(msg.GetQty())})
+	}
+
+	return items
+}
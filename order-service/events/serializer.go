@@ -0,0 +1,109 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// Serializer mengubah envelope ke bentuk wire untuk satu content type.
+type Serializer interface {
+	ContentType() string
+	Supports(eventType string) bool
+	Marshal(env Envelope) ([]byte, error)
+	Unmarshal(body []byte) (Envelope, error)
+}
+
+var serializers = map[string]Serializer{
+	ContentTypeJSON:        jsonSerializer{},
+	"text/plain":           jsonSerializer{},
+	ContentTypeProtobuf:    protobufSerializer{},
+	"application/protobuf": protobufSerializer{},
+}
+
+// jsonOnlyTypes dikonsumsi oleh product-service (NestJS) yang hanya membaca
+// JSON, sehingga selalu dikirim sebagai JSON apa pun EVENT_CONTENT_TYPE-nya.
+var jsonOnlyTypes = map[string]bool{
+	TypeOrderCreated:   true,
+	TypeOrderCancelled: true,
+	TypeStockReserve:   true,
+	TypeStockRelease:   true,
+}
+
+// SerializerFor memilih serializer berdasarkan header content-type. Header
+// kosong dianggap JSON agar message lama dan message dari NestJS tetap terbaca.
+func SerializerFor(contentType string) (Serializer, error) {
+	if contentType == "" {
+		return jsonSerializer{}, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+
+	serializer, ok := serializers[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+
+	return serializer, nil
+}
+
+// Marshal meng-encode envelope dengan content type dari EVENT_CONTENT_TYPE.
+// Event yang tidak didukung serializer tersebut dikirim sebagai JSON.
+func Marshal(env Envelope) (string, []byte, error) {
+	serializer, err := SerializerFor(os.Getenv("EVENT_CONTENT_TYPE"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	if jsonOnlyTypes[env.Type] || !serializer.Supports(env.Type) {
+		serializer = jsonSerializer{}
+	}
+
+	body, err := serializer.Marshal(env)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return serializer.ContentType(), body, nil
+}
+
+type jsonSerializer struct{}
+
+func (jsonSerializer) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonSerializer) Supports(eventType string) bool {
+	_, ok := latestVersions[eventType]
+	return ok
+}
+
+func (jsonSerializer) Marshal(env Envelope) ([]byte, error) {
+	body, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", env.Type, err)
+	}
+
+	return body, nil
+}
+
+func (jsonSerializer) Unmarshal(body []byte) (Envelope, error) {
+	var env Envelope
+	if err := decodeStrict(body, &env); err != nil {
+		return Envelope{}, err
+	}
+
+	return env, nil
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestSerializerFor(t *testing.T) {
+	t.Run("should fall back to JSON when the header is missing", func(t *testing.T) {
+		serializer, err := SerializerFor("")
+
+		assert.NoError(t, err)
+		assert.Equal(t, ContentTypeJSON, serializer.ContentType())
+	})
+
+	t.Run("should ignore media type parameters", func(t *testing.T) {
+		serializer, err := SerializerFor("application/x-protobuf; proto=orderservice.events.v1.Envelope")
+
+		assert.NoError(t, err)
+		assert.Equal(t, ContentTypeProtobuf, serializer.ContentType())
+	})
+
+	t.Run("should reject unknown content types", func(t *testing.T) {
+		_, err := SerializerFor("application/avro")
+
+		assert.ErrorIs(t, err, ErrUnsupportedContentType)
+	})
+}
+
+func TestMarshal(t *testing.T) {
+	t.Run("should publish JSON by default", func(t *testing.T) {
+		env, _ := New(TypeOrderFailed, OrderFailed{OrderID: 10, Reason: "Out of stock"}, "")
+
+		contentType, body, err := Marshal(env)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ContentTypeJSON, contentType)
+
+		var data OrderFailed
+		_, err = Decode(contentType, body, TypeOrderFailed, &data)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should use the configured content type", func(t *testing.T) {
+		t.Setenv("EVENT_CONTENT_TYPE", ContentTypeProtobuf)
+		env, _ := New(TypeOrderCreatedRequest, OrderCreatedRequest{OrderID: 10, Items: []OrderItem{{ProductID: 123, Qty: 2}}}, "")
+
+		contentType, _, err := Marshal(env)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ContentTypeProtobuf, contentType)
+	})
+
+	t.Run("should keep JSON for events consumed by NestJS", func(t *testing.T) {
+		t.Setenv("EVENT_CONTENT_TYPE", ContentTypeProtobuf)
+		env, _ := New(TypeStockReserve, StockRequest{OrderID: 10, Items: []OrderItem{{ProductID: 123, Qty: 2}}}, "")
+
+		contentType, body, err := Marshal(env)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ContentTypeJSON, contentType)
+		assert.Contains(t, string(body), `"pattern":"stock.reserve"`)
+	})
+
+	t.Run("should fail on an unknown configured content type", func(t *testing.T) {
+		t.Setenv("EVENT_CONTENT_TYPE", "application/avro")
+		env, _ := New(TypeOrderFailed, OrderFailed{OrderID: 10, Reason: "Out of stock"}, "")
+
+		_, _, err := Marshal(env)
+
+		assert.ErrorIs(t, err, ErrUnsupportedContentType)
+	})
+}
+
+func TestProtobufSerializer(t *testing.T) {
+	t.Run("should round trip every order and stock event", func(t *testing.T) {
+		items := []OrderItem{{ProductID: 123, Qty: 2}, {ProductID: 456, Qty: 1}}
+		cases := []struct {
+			eventType string
+			data      interface{}
+			decoded   interface{}
+		}{
+			{TypeOrderCreatedRequest, OrderCreatedRequest{OrderID: 10, Items: items}, &OrderCreatedRequest{}},
+			{TypeOrderCreated, OrderCreated{OrderID: 10, ProductID: 123, Qty: 2}, &OrderCreated{}},
+			{TypeOrderFailed, OrderFailed{OrderID: 10, Items: items, Reason: "Out of stock"}, &OrderFailed{}},
+			{TypeOrderCancelled, OrderCancelled{OrderID: 10, ProductID: 123, Qty: 2, Restock: true}, &OrderCancelled{}},
+			{TypeStockRelease, StockRequest{OrderID: 10, Items: items}, &StockRequest{}},
+			{TypeStockRejected, StockReply{OrderID: 10, Reason: "Out of stock"}, &StockReply{}},
+		}
+
+		for _, c := range cases {
+			env, err := New(c.eventType, c.data, "corr-1")
+			assert.NoError(t, err)
+
+			body, err := protobufSerializer{}.Marshal(env)
+			assert.NoError(t, err)
+
+			decoded, err := Decode(ContentTypeProtobuf, body, c.eventType, c.decoded)
+
+			assert.NoError(t, err, c.eventType)
+			assert.Equal(t, env.ID, decoded.ID)
+			assert.Equal(t, c.eventType, decoded.Pattern)
+			assert.Equal(t, 1, decoded.Version)
+			assert.Equal(t, "corr-1", decoded.CorrelationID)
+			assert.True(t, env.OccurredAt.Equal(decoded.OccurredAt))
+			assert.Equal(t, time.UTC, decoded.OccurredAt.Location())
+			assert.Equal(t, c.data, reflect.ValueOf(c.decoded).Elem().Interface(), c.eventType)
+		}
+	})
+
+	t.Run("should decode data without converting it to JSON", func(t *testing.T) {
+		env, _ := New(TypeOrderFailed, OrderFailed{OrderID: 10, Reason: "Out of stock"}, "")
+		body, _ := protobufSerializer{}.Marshal(env)
+
+		var data OrderFailed
+		decoded, err := Decode(ContentTypeProtobuf, body, TypeOrderFailed, &data)
+
+		assert.NoError(t, err)
+		assert.Empty(t, decoded.Data)
+
+		// Expect the JSON form to be built only on request
+		raw, err := decoded.JSON()
+		assert.NoError(t, err)
+		assert.Contains(t, string(raw), `"data":{"orderID":10,"items":null,"reason":"Out of stock"}`)
+	})
+
+	t.Run("should validate decoded data", func(t *testing.T) {
+		env, _ := New(TypeStockReserve, StockRequest{OrderID: 10, Items: []OrderItem{{ProductID: 123, Qty: 2}}}, "")
+		env.payload = StockRequest{OrderID: 10}
+		body, _ := protobufSerializer{}.Marshal(env)
+
+		var data StockRequest
+		_, err := Decode(ContentTypeProtobuf, body, TypeStockReserve, &data)
+
+		assert.ErrorIs(t, err, ErrInvalidEvent)
+	})
+
+	t.Run("should reject data decoded into another type", func(t *testing.T) {
+		env, _ := New(TypeStockReserved, StockReply{OrderID: 10}, "")
+		body, _ := protobufSerializer{}.Marshal(env)
+
+		decoded, err := DecodeEnvelope(ContentTypeProtobuf, body)
+		assert.NoError(t, err)
+
+		var data OrderFailed
+		assert.ErrorIs(t, decoded.DecodeData(&data), ErrInvalidEvent)
+	})
+
+	t.Run("should skip unknown fields for forward compatibility", func(t *testing.T) {
+		env, _ := New(TypeStockReserved, StockReply{OrderID: 10}, "")
+		body, _ := protobufSerializer{}.Marshal(env)
+		body = protowire.AppendTag(body, 99, protowire.BytesType)
+		body = protowire.AppendString(body, "added in a later schema")
+
+		var data StockReply
+		_, err := Decode(ContentTypeProtobuf, body, TypeStockReserved, &data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, StockReply{OrderID: 10}, data)
+	})
+
+	t.Run("should reject truncated messages", func(t *testing.T) {
+		env, _ := New(TypeStockReserved, StockReply{OrderID: 10}, "")
+		body, _ := protobufSerializer{}.Marshal(env)
+
+		var data StockReply
+		_, err := Decode(ContentTypeProtobuf, body[:len(body)-1], TypeStockReserved, &data)
+
+		assert.ErrorIs(t, err, ErrInvalidEvent)
+	})
+
+	t.Run("should reject events without a protobuf schema", func(t *testing.T) {
+		env, _ := New(TypeProductDeleted, Product{ID: 1}, "")
+
+		_, err := protobufSerializer{}.Marshal(env)
+
+		assert.ErrorIs(t, err, ErrUnsupportedContentType)
+	})
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	TypeOrderCreatedRequest = "order.created.request"
+	TypeOrderCreated        = "order.created"
+	TypeOrderFailed         = "order.failed"
+	TypeOrderCancelled      = "order.cancelled"
+	TypeStockReserve        = "stock.reserve"
+	TypeStockRelease        = "stock.release"
+	TypeStockReserved       = "stock.reserved"
+	TypeStockRejected       = "stock.rejected"
+	TypeProductCreated      = "product.created"
+	TypeProductUpdated      = "product.updated"
+	TypeProductDeleted      = "product.deleted"
+)
+
+// latestVersions berisi versi terbaru setiap tipe event. Versi di atasnya
+// ditolak oleh DecodeEnvelope.
+var latestVersions = map[string]int{
+	TypeOrderCreatedRequest: 1,
+	TypeOrderCreated:        1,
+	TypeOrderFailed:         1,
+	TypeOrderCancelled:      1,
+	TypeStockReserve:        1,
+	TypeStockRelease:        1,
+	TypeStockReserved:       1,
+	TypeStockRejected:       1,
+	TypeProductCreated:      1,
+	TypeProductUpdated:      1,
+	TypeProductDeleted:      1,
+}
+
+type OrderItem struct {
+	ProductID uint `json:"productID" validate:"required"`
+	Qty       int  `json:"qty" validate:"required,gt=0"`
+}
+
+type OrderCreatedRequest struct {
+	OrderID uint        `json:"orderID" validate:"required"`
+	Items   []OrderItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// OrderCreated dikirim per item, sesuai payload reduceStock di product-service.
+// StockReserved bernilai true jika stok sudah diambil oleh stock.reserve,
+// sehingga reduceStock tidak boleh menguranginya lagi.
+type OrderCreated struct {
+	OrderID       uint `json:"orderID" validate:"required"`
+	ProductID     uint `json:"productID" validate:"required"`
+	Qty           int  `json:"qty" validate:"required,gt=0"`
+	StockReserved bool `json:"stockReserved,omitempty"`
+}
+
+type OrderFailed struct {
+	OrderID uint        `json:"orderID" validate:"required"`
+	Items   []OrderItem `json:"items" validate:"dive"`
+	Reason  string      `json:"reason" validate:"required"`
+}
+
+type OrderCancelled struct {
+	OrderID   uint `json:"orderID" validate:"required"`
+	ProductID uint `json:"productID" validate:"required"`
+	Qty       int  `json:"qty" validate:"required,gt=0"`
+	Restock   bool `json:"restock"`
+}
+
+// StockRequest adalah data stock.reserve dan stock.release.
+type StockRequest struct {
+	OrderID uint        `json:"orderID" validate:"required"`
+	Items   []OrderItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// StockReply adalah data stock.reserved dan stock.rejected.
+type StockReply struct {
+	OrderID uint   `json:"orderID" validate:"required"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Product adalah data product.created/updated/deleted dari product-service.
+type Product struct {
+	ID        uint       `json:"id" validate:"required"`
+	Name      string     `json:"name,omitempty"`
+	Price     float64    `json:"price" validate:"gte=0"`
+	Qty       int        `json:"qty" validate:"gte=0"`
+	Version   int64      `json:"version,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// partitionKeyer diimplementasikan oleh data event yang urutannya harus
+// dijaga per key di broker yang mendukung partisi.
+type partitionKeyer interface {
+	partitionKey() string
+}
+
+// PartitionKey mengembalikan key partisi untuk data event. Event per item
+// memakai productID agar urutan per produk terjaga; event lain memakai orderID.
+func PartitionKey(data interface{}) string {
+	if k, ok := data.(partitionKeyer); ok {
+		return k.partitionKey()
+	}
+
+	return ""
+}
+
+func (e OrderCreatedRequest) partitionKey() string { return formatKey(e.OrderID) }
+func (e OrderCreated) partitionKey() string        { return formatKey(e.ProductID) }
+func (e OrderFailed) partitionKey() string         { return formatKey(e.OrderID) }
+func (e OrderCancelled) partitionKey() string      { return formatKey(e.ProductID) }
+func (e StockRequest) partitionKey() string        { return formatKey(e.OrderID) }
+func (e StockReply) partitionKey() string          { return formatKey(e.OrderID) }
+func (e Product) partitionKey() string             { return formatKey(e.ID) }
+
+func formatKey(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
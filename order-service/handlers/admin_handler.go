@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"order-service/dto/request"
+	"order-service/dto/response"
+	"order-service/helpers"
+	"order-service/services"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type AdminHandler struct {
+	failedOrderService services.FailedOrderService
+	orderService       services.OrderService
+}
+
+func NewAdminHandler(failedOrderService services.FailedOrderService, orderService services.OrderService) *AdminHandler {
+	return &AdminHandler{
+		failedOrderService: failedOrderService,
+		orderService:       orderService,
+	}
+}
+
+// FindAllOrders sama dengan GET /orders, ditambah opsi include_deleted.
+func (h *AdminHandler) FindAllOrders(c echo.Context) error {
+	req := new(request.AdminFindOrdersRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   err.Error(),
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   helpers.TranslateValidationErr(err).Error(),
+		})
+	}
+
+	return findOrders(c, h.orderService, &req.FindOrdersRequest, req.IncludeDeleted)
+}
+
+func (h *AdminHandler) FindAllFailedOrders(c echo.Context) error {
+	req := new(request.FindFailedOrdersRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   err.Error(),
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   helpers.TranslateValidationErr(err).Error(),
+		})
+	}
+
+	page, err := h.failedOrderService.FindAll(c.Request().Context(), req.Limit, req.Offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusInternalServerError),
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.BaseResponse{
+		Status:  true,
+		Message: http.StatusText(http.StatusOK),
+		Data:    page.FailedOrders,
+		Pagination: &response.Pagination{
+			Limit:   page.Limit,
+			Offset:  page.Offset,
+			Total:   page.Total,
+			HasMore: page.HasMore,
+		},
+	})
+}
+
+func (h *AdminHandler) ReplayFailedOrder(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   err.Error(),
+		})
+	}
+
+	failedOrder, err := h.failedOrderService.Replay(c.Request().Context(), uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrOrderNotReplayable):
+			status = http.StatusConflict
+		}
+
+		return c.JSON(status, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(status),
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, response.BaseResponse{
+		Status:  true,
+		Message: http.StatusText(http.StatusAccepted),
+		Data:    failedOrder,
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"order-service/dto/response"
+	"order-service/messaging"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HealthHandler struct {
+	messaging messaging.MessagingService
+	broker    string
+}
+
+func NewHealthHandler(messaging messaging.MessagingService, broker string) *HealthHandler {
+	return &HealthHandler{
+		messaging: messaging,
+		broker:    broker,
+	}
+}
+
+func (h *HealthHandler) Health(c echo.Context) error {
+	state := h.messaging.State()
+
+	data := map[string]interface{}{
+		"broker": map[string]interface{}{
+			"name":  h.broker,
+			"state": state,
+		},
+	}
+
+	if state != messaging.StateConnected {
+		return c.JSON(http.StatusServiceUnavailable, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusServiceUnavailable),
+			Data:    data,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.BaseResponse{
+		Status:  true,
+		Message: http.StatusText(http.StatusOK),
+		Data:    data,
+	})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"order-service/dto/request"
 	"order-service/dto/response"
@@ -8,8 +10,11 @@ import (
 	"order-service/helpers"
 	"order-service/services"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 type OrderHandler struct {
@@ -40,34 +45,91 @@ func (h *OrderHandler) CreateOrder(c echo.Context) error {
 		})
 	}
 
+	idempotencyKey := c.Request().Header.Get("Idempotency-Key")
+	if len(idempotencyKey) > 255 {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   "idempotency key must not exceed 255 characters",
+		})
+	}
+
 	order := entities.Order{
-		ProductID: req.ProductID,
-		Qty:       req.Qty,
-		Status:    "pending",
+		Status: entities.OrderStatusPending,
+	}
+
+	if len(req.Items) == 0 {
+		order.Items = []entities.OrderItem{{ProductID: req.ProductID, Qty: req.Qty}}
 	}
 
-	_, err := h.orderService.Create(order)
+	for _, item := range req.Items {
+		order.Items = append(order.Items, entities.OrderItem{ProductID: item.ProductID, Qty: item.Qty})
+	}
+
+	createdOrder, err := h.orderService.Create(c.Request().Context(), order, idempotencyKey)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, response.BaseResponse{
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+			status = http.StatusConflict
+		}
+
+		return c.JSON(status, response.BaseResponse{
 			Status:  false,
-			Message: http.StatusText(http.StatusInternalServerError),
+			Message: http.StatusText(status),
 			Error:   err.Error(),
 		})
 	}
 
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/orders/%d", createdOrder.ID))
+
 	return c.JSON(http.StatusAccepted, response.BaseResponse{
 		Status:  true,
 		Message: http.StatusText(http.StatusAccepted),
 		Data: map[string]interface{}{
-			"product_id": order.ProductID,
-			"qty":        order.Qty,
-			"status":     order.Status,
+			"id":     createdOrder.ID,
+			"items":  createdOrder.Items,
+			"status": createdOrder.Status,
 		},
 	})
 }
 
 func (h *OrderHandler) FindAllOrders(c echo.Context) error {
-	orders, err := h.orderService.FindAll()
+	req := new(request.FindOrdersRequest)
+	if err := c.Bind(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   err.Error(),
+		})
+	}
+
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   helpers.TranslateValidationErr(err).Error(),
+		})
+	}
+
+	return findOrders(c, h.orderService, req, false)
+}
+
+// findOrders dipakai bersama oleh GET /orders dan GET /admin/orders.
+func findOrders(c echo.Context, orderService services.OrderService, req *request.FindOrdersRequest, includeDeleted bool) error {
+	filter, err := toOrderFilter(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   err.Error(),
+		})
+	}
+	filter.IncludeDeleted = includeDeleted
+
+	page, err := orderService.FindAll(c.Request().Context(), filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, response.BaseResponse{
 			Status:  false,
@@ -79,10 +141,56 @@ func (h *OrderHandler) FindAllOrders(c echo.Context) error {
 	return c.JSON(http.StatusOK, response.BaseResponse{
 		Status:  false,
 		Message: http.StatusText(http.StatusOK),
-		Data:    orders,
+		Data:    page.Orders,
+		Pagination: &response.Pagination{
+			Limit:      page.Limit,
+			Offset:     page.Offset,
+			Total:      page.Total,
+			HasMore:    page.HasMore,
+			NextCursor: page.NextCursor,
+		},
 	})
 }
 
+func toOrderFilter(req *request.FindOrdersRequest) (entities.OrderFilter, error) {
+	filter := entities.OrderFilter{
+		Status:    entities.OrderStatus(req.Status),
+		ProductID: req.ProductID,
+		Limit:     req.Limit,
+		Offset:    req.Offset,
+		SortBy:    entities.OrderSortByCreatedAt,
+		SortDesc:  true,
+	}
+
+	if req.Sort != "" {
+		filter.SortDesc = strings.HasPrefix(req.Sort, "-")
+		filter.SortBy = strings.TrimPrefix(req.Sort, "-")
+	}
+
+	if req.Cursor != "" {
+		if req.Offset > 0 {
+			return entities.OrderFilter{}, errors.New("cursor and offset cannot be combined")
+		}
+
+		cursor, err := entities.DecodeOrderCursor(req.Cursor)
+		if err != nil {
+			return entities.OrderFilter{}, err
+		}
+		filter.Cursor = cursor
+	}
+
+	// Format sudah divalidasi oleh tag datetime.
+	if req.CreatedFrom != "" {
+		filter.CreatedFrom, _ = time.Parse(time.RFC3339, req.CreatedFrom)
+	}
+
+	if req.CreatedTo != "" {
+		filter.CreatedTo, _ = time.Parse(time.RFC3339, req.CreatedTo)
+	}
+
+	return filter, nil
+}
+
 func (h *OrderHandler) FindOrderByID(c echo.Context) error {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
@@ -93,7 +201,7 @@ func (h *OrderHandler) FindOrderByID(c echo.Context) error {
 		})
 	}
 
-	order, err := h.orderService.FindByID(uint(id))
+	order, err := h.orderService.FindByID(c.Request().Context(), uint(id))
 	if err != nil {
 		return c.JSON(http.StatusNotFound, response.BaseResponse{
 			Status:  false,
@@ -119,7 +227,7 @@ func (h *OrderHandler) FindOrdersByProductID(c echo.Context) error {
 		})
 	}
 
-	orders, err := h.orderService.FindByProductID(uint(productID))
+	orders, err := h.orderService.FindByProductID(c.Request().Context(), uint(productID))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, response.BaseResponse{
 			Status:  false,
@@ -164,14 +272,24 @@ func (h *OrderHandler) UpdateOrder(c echo.Context) error {
 
 	order := entities.Order{
 		ID:     uint(id),
-		Status: req.Status,
+		Status: entities.OrderStatus(req.Status),
 	}
 
-	updatedOrder, err := h.orderService.Update(order)
+	updatedOrder, err := h.orderService.Update(c.Request().Context(), order, req.Reason)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, response.BaseResponse{
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrInvalidOrderStatus):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrInvalidStatusTransition):
+			status = http.StatusConflict
+		}
+
+		return c.JSON(status, response.BaseResponse{
 			Status:  false,
-			Message: http.StatusText(http.StatusInternalServerError),
+			Message: http.StatusText(status),
 			Error:   err.Error(),
 		})
 	}
@@ -193,13 +311,120 @@ func (h *OrderHandler) DeleteOrder(c echo.Context) error {
 		})
 	}
 
-	if err := h.orderService.Delete(uint(id)); err != nil {
-		return c.JSON(http.StatusInternalServerError, response.BaseResponse{
+	if err := h.orderService.Delete(c.Request().Context(), uint(id)); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrOrderNotDeletable):
+			status = http.StatusConflict
+		}
+
+		return c.JSON(status, response.BaseResponse{
 			Status:  false,
-			Message: http.StatusText(http.StatusInternalServerError),
+			Message: http.StatusText(status),
 			Error:   err.Error(),
 		})
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+func (h *OrderHandler) CancelOrder(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   err.Error(),
+		})
+	}
+
+	cancelledOrder, err := h.orderService.Cancel(c.Request().Context(), uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrOrderNotCancellable):
+			status = http.StatusConflict
+		}
+
+		return c.JSON(status, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(status),
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.BaseResponse{
+		Status:  true,
+		Message: http.StatusText(http.StatusOK),
+		Data:    cancelledOrder,
+	})
+}
+
+func (h *OrderHandler) FindOrderHistory(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   err.Error(),
+		})
+	}
+
+	history, err := h.orderService.FindHistory(c.Request().Context(), uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+
+		return c.JSON(status, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(status),
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.BaseResponse{
+		Status:  true,
+		Message: http.StatusText(http.StatusOK),
+		Data:    history,
+	})
+}
+
+func (h *OrderHandler) RestoreOrder(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(http.StatusBadRequest),
+			Error:   err.Error(),
+		})
+	}
+
+	restoredOrder, err := h.orderService.Restore(c.Request().Context(), uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrOrderNotDeleted):
+			status = http.StatusConflict
+		}
+
+		return c.JSON(status, response.BaseResponse{
+			Status:  false,
+			Message: http.StatusText(status),
+			Error:   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response.BaseResponse{
+		Status:  true,
+		Message: http.StatusText(http.StatusOK),
+		Data:    restoredOrder,
+	})
+}
@@ -0,0 +1,20 @@
+package helpers
+
+import "context"
+
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor menyimpan siapa yang melakukan perubahan, untuk dicatat di audit trail.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return SystemActor
+}
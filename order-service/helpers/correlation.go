@@ -0,0 +1,16 @@
+package helpers
+
+import "context"
+
+type correlationIDKey struct{}
+
+// WithCorrelationID menyimpan correlation ID yang diteruskan ke setiap event
+// yang dibuat dari request atau message ini.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
@@ -2,11 +2,20 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"order-service/clients"
 	"order-service/database"
+	"order-service/events"
 	"order-service/handlers"
 	"order-service/messaging"
 	"order-service/middlewares"
@@ -18,6 +27,8 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	err := godotenv.Load()
 	if err != nil {
@@ -28,9 +39,26 @@ func main() {
 
 	cacheService := database.NewRedisService()
 
-	msgService := messaging.NewRabbitMQService()
-	if err := msgService.ConnectRabbitMQ(); err != nil {
-		log.Fatalf("Could not connect to RabbitMQ: %v", err)
+	if _, err := events.SerializerFor(os.Getenv("EVENT_CONTENT_TYPE")); err != nil {
+		log.Fatalf("Invalid EVENT_CONTENT_TYPE: %v", err)
+	}
+
+	adminCredentials, err := middlewares.ParseAdminCredentials(os.Getenv("ADMIN_TOKENS"))
+	if err != nil {
+		log.Fatalf("Invalid ADMIN_TOKENS: %v", err)
+	}
+
+	if len(adminCredentials) == 0 {
+		log.Println("ADMIN_TOKENS is empty, every /admin request will be rejected")
+	}
+
+	msgService, err := messaging.NewMessagingService()
+	if err != nil {
+		log.Fatalf("Invalid message broker: %v", err)
+	}
+
+	if err := msgService.Connect(); err != nil {
+		log.Fatalf("Could not connect to message broker: %v", err)
 	}
 
 	e := echo.New()
@@ -45,6 +73,8 @@ func main() {
 	loggerMiddleware := loggerConfig.Init()
 	e.Use(loggerMiddleware)
 	e.Use(middleware.Recover())
+	e.Use(middlewares.Actor(adminCredentials))
+	e.Use(middlewares.CorrelationID())
 
 	// Validator
 	customValidator := middlewares.InitValidator()
@@ -53,19 +83,108 @@ func main() {
 
 	// Init routes
 	repo := repositories.NewOrderRepository(db)
-	service := services.NewOrderService(repo, &http.Client{Timeout: 10 * time.Second}, msgService, cacheService)
+	processedMessageRepo := repositories.NewProcessedMessageRepository(db)
+	statusHistoryRepo := repositories.NewOrderStatusHistoryRepository(db)
+	transactor := repositories.NewTransactor(db)
+	// Timeout per percobaan; retry dan circuit breaker ditangani oleh resilient client.
+	productHTTPClient := clients.NewResilientHTTPClient(&http.Client{Timeout: 3 * time.Second}, clients.DefaultResilientClientConfig)
+	productClient := clients.NewProductClient(os.Getenv("PRODUCT_SERVICE_URL"), productHTTPClient)
+	productCatalog := clients.NewProductCatalog(cacheService, productClient)
+	// Katalog hanya dipakai untuk harga. Tanpa saga, stok dicek dari qty produk,
+	// sehingga harus dibaca langsung dari product-service: product-service tidak
+	// mengirim event saat stok berubah.
+	orderProducts := clients.ProductClient(productClient)
+	if services.StockSagaEnabled() {
+		orderProducts = productCatalog
+	}
+	service := services.NewOrderService(repo, processedMessageRepo, statusHistoryRepo, transactor, orderProducts, msgService, cacheService)
+	outboxRelay := services.NewOutboxRelay(transactor, msgService)
+	productCatalogService := services.NewProductCatalogService(productCatalog, msgService)
+	stockSweeper := services.NewStockReservationSweeper(transactor, cacheService)
+
+	failedOrderRepo := repositories.NewFailedOrderRepository(db)
+	failedOrderService := services.NewFailedOrderService(failedOrderRepo, transactor, cacheService)
+	adminHandler := handlers.NewAdminHandler(failedOrderService, service)
 	handler := handlers.NewOrderHandler(service)
 
+	healthHandler := handlers.NewHealthHandler(msgService, messaging.BrokerName())
+	e.GET("/health", healthHandler.Health)
+	e.GET("/debug/vars", echo.WrapHandler(expvar.Handler()))
+
 	order := e.Group("/orders")
 	order.POST("", handler.CreateOrder)
 	order.GET("", handler.FindAllOrders)
 	order.GET("/:id", handler.FindOrderByID)
 	order.GET("/product/:productID", handler.FindOrdersByProductID)
 	order.PUT("/:id", handler.UpdateOrder)
+	order.POST("/:id/cancel", handler.CancelOrder)
+	order.GET("/:id/history", handler.FindOrderHistory)
 	order.DELETE("/:id", handler.DeleteOrder)
+	order.POST("/:id/restore", handler.RestoreOrder, middlewares.AdminAuth(adminCredentials))
+
+	admin := e.Group("/admin", middlewares.AdminAuth(adminCredentials))
+	admin.GET("/orders", adminHandler.FindAllOrders)
+	admin.GET("/failed-orders", adminHandler.FindAllFailedOrders)
+	admin.POST("/failed-orders/:id/replay", adminHandler.ReplayFailedOrder)
+
+	// Background workers get their own context so they keep running while
+	// in-flight HTTP requests are drained.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
 
-	go service.StartOrderConsumer()
-	go service.StartOrderFailedConsumer()
+	runWorker := func(start func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			start(workerCtx)
+		}()
+	}
+
+	runWorker(service.StartOrderConsumer)
+	runWorker(service.StartOrderFailedConsumer)
+	runWorker(service.StartStockReplyConsumer)
+	runWorker(productCatalogService.StartProductEventConsumer)
+	runWorker(stockSweeper.Start)
+	runWorker(outboxRelay.Start)
+
+	go func() {
+		if err := e.Start(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	<-signalCtx.Done()
+	log.Println("Shutting down order-service...")
+
+	// 1. Stop accepting requests and drain the in-flight ones
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down HTTP server: %v", err)
+	}
+
+	// 2. Stop consumers after their current delivery and flush the outbox
+	stopWorkers()
+	workers.Wait()
+
+	// 3. Close connections in reverse order of dependency
+	if err := msgService.Close(); err != nil {
+		log.Printf("Failed to close RabbitMQ connection: %v", err)
+	}
+
+	if err := cacheService.Close(); err != nil {
+		log.Printf("Failed to close Redis client: %v", err)
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("Failed to close database pool: %v", err)
+		}
+	}
 
-	e.Logger.Fatal(e.Start(":8080"))
+	log.Println("order-service stopped")
 }
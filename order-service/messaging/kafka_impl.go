@@ -0,0 +1,500 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	kafkaBatchTimeout = 10 * time.Millisecond
+
+	kafkaMessageIDHeader   = "message-id"
+	kafkaContentTypeHeader = "content-type"
+	kafkaRoutingKeyHeader  = "routing-key"
+)
+
+// kafkaWriter dan kafkaCommitter adalah bagian dari kafka.Writer dan
+// kafka.Reader yang dipakai di sini, agar bisa diganti di test.
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+type kafkaCommitter interface {
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// kafkaService mengimplementasikan MessagingService di atas Kafka. Routing key
+// menjadi nama topic (exchange diabaikan), partitionKey menjadi key message
+// sehingga event dengan key yang sama masuk partisi yang sama dan terurut, dan
+// setiap queue menjadi consumer group. Retry memakai topic <queue>.retry.N dan
+// DLQ memakai topic <queue>.dlq.
+type kafkaService struct {
+	brokers []string
+	writer  kafkaWriter
+	state   ConnectionState
+	done    chan struct{}
+	mu      sync.RWMutex
+}
+
+func NewKafkaService() *kafkaService {
+	brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+	if os.Getenv("KAFKA_BROKERS") == "" {
+		brokers = []string{"localhost:9092"}
+	}
+
+	return &kafkaService{
+		brokers: brokers,
+		state:   StateDisconnected,
+		done:    make(chan struct{}),
+	}
+}
+
+// Connect memastikan broker bisa dihubungi lalu menyiapkan writer. Koneksi
+// berikutnya dikelola oleh writer dan reader kafka-go.
+func (s *kafkaService) Connect() error {
+	s.setState(StateConnecting)
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	conn, err := kafka.DialContext(ctx, "tcp", s.brokers[0])
+	if err != nil {
+		s.setState(StateDisconnected)
+		return fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	conn.Close()
+
+	s.mu.Lock()
+	s.writer = &kafka.Writer{
+		Addr:                   kafka.TCP(s.brokers...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		BatchTimeout:           kafkaBatchTimeout,
+		AllowAutoTopicCreation: true,
+	}
+	s.mu.Unlock()
+
+	s.setState(StateConnected)
+	log.Println("Kafka connection successfully opened!")
+	return nil
+}
+
+func (s *kafkaService) State() ConnectionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state
+}
+
+func (s *kafkaService) setState(state ConnectionState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == StateClosed {
+		return
+	}
+
+	s.state = state
+}
+
+func (s *kafkaService) messageWriter() (kafkaWriter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.writer == nil || s.state == StateClosed {
+		return nil, fmt.Errorf("Kafka connection is not established")
+	}
+
+	return s.writer, nil
+}
+
+// PublishEvent menulis message ke topic routingKey dan baru kembali setelah
+// semua replica mengonfirmasinya. Message tanpa partitionKey dibagi rata ke
+// semua partisi.
+func (s *kafkaService) PublishEvent(ctx context.Context, exchangeName, routingKey, partitionKey, messageID, contentType string, body []byte) error {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	writer, err := s.messageWriter()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withPublishTimeout(ctx)
+	defer cancel()
+
+	var key []byte
+	if partitionKey != "" {
+		key = []byte(partitionKey)
+	}
+
+	err = writer.WriteMessages(ctx, kafka.Message{
+		Topic: routingKey,
+		Key:   key,
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: kafkaMessageIDHeader, Value: []byte(messageID)},
+			{Key: kafkaContentTypeHeader, Value: []byte(contentType)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *kafkaService) Consume(ctx context.Context, queueName string, routingKey string) (<-chan Message, error) {
+	return s.ConsumeExchange(ctx, os.Getenv("RABBITMQ_EXCHANGE_NAME"), queueName, routingKey)
+}
+
+// ConsumeExchange membaca topic routing key dengan consumer group queueName,
+// ditambah satu reader per retry topic. Setiap reader menunggu message-nya
+// diselesaikan sebelum mengambil message berikutnya, sehingga offset hanya
+// di-commit setelah message berhasil diproses dan tidak pernah melompati
+// message yang belum selesai.
+func (s *kafkaService) ConsumeExchange(ctx context.Context, exchangeName, queueName string, routingKeys ...string) (<-chan Message, error) {
+	writer, err := s.messageWriter()
+	if err != nil {
+		return nil, err
+	}
+
+	topics := append([]string{}, routingKeys...)
+	for i := range RetryDelays {
+		topics = append(topics, kafkaRetryTopic(queueName, i+1))
+	}
+	topics = append(topics, kafkaDeadLetterTopic(queueName))
+
+	if err := s.ensureTopics(ctx, topics...); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	out := make(chan Message)
+	var wg sync.WaitGroup
+
+	start := func(config kafka.ReaderConfig, delay time.Duration) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.consumeGroup(ctx, writer, queueName, config, delay, out)
+		}()
+	}
+
+	start(s.readerConfig(queueName, routingKeys), 0)
+	for i, delay := range RetryDelays {
+		retryTopic := kafkaRetryTopic(queueName, i+1)
+		start(s.readerConfig(retryTopic, []string{retryTopic}), delay)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+func (s *kafkaService) readerConfig(groupID string, topics []string) kafka.ReaderConfig {
+	return kafka.ReaderConfig{
+		Brokers:     s.brokers,
+		GroupID:     groupID,
+		GroupTopics: topics,
+		StartOffset: kafka.FirstOffset,
+		MaxWait:     time.Second,
+	}
+}
+
+// consumeGroup menjalankan reader untuk satu consumer group. Saat message
+// di-Nack dengan requeue, reader dibuka ulang agar membaca lagi dari offset
+// terakhir yang sudah di-commit.
+func (s *kafkaService) consumeGroup(ctx context.Context, writer kafkaWriter, queueName string, config kafka.ReaderConfig, delay time.Duration, out chan<- Message) {
+	for {
+		reader := kafka.NewReader(config)
+		rewind := s.deliver(ctx, reader, writer, queueName, delay, out)
+		reader.Close()
+
+		if !rewind || ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("Consumer for queue %s rewinding to the last committed offset", queueName)
+	}
+}
+
+// deliver mengirim message dari reader satu per satu dan mengembalikan true
+// jika reader harus dibuka ulang karena message di-requeue. delay menunda
+// message retry sampai waktu tulisnya ditambah jeda attempt tersebut.
+func (s *kafkaService) deliver(ctx context.Context, reader *kafka.Reader, writer kafkaWriter, queueName string, delay time.Duration, out chan<- Message) bool {
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return false
+			}
+
+			log.Printf("Consumer for queue %s failed to fetch messages: %v", queueName, err)
+
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(reconnectInitialDelay):
+			}
+			continue
+		}
+
+		if wait := time.Until(msg.Time.Add(delay)); delay > 0 && wait > 0 {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-time.After(wait):
+			}
+		}
+
+		ack := newKafkaAcknowledger(reader, writer, queueName, msg)
+
+		select {
+		case out <- newKafkaMessage(msg, ack):
+		case <-ctx.Done():
+			return false
+		}
+
+		select {
+		case requeue := <-ack.settled:
+			if requeue {
+				return true
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// ensureTopics membuat topic yang belum ada dengan jumlah partisi dan
+// replication factor default dari broker.
+func (s *kafkaService) ensureTopics(ctx context.Context, topics ...string) error {
+	client := &kafka.Client{Addr: kafka.TCP(s.brokers...), Timeout: publishTimeout}
+
+	configs := make([]kafka.TopicConfig, 0, len(topics))
+	for _, topic := range topics {
+		configs = append(configs, kafka.TopicConfig{Topic: topic, NumPartitions: -1, ReplicationFactor: -1})
+	}
+
+	resp, err := client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: configs})
+	if err != nil {
+		return fmt.Errorf("failed to create topics: %w", err)
+	}
+
+	for topic, err := range resp.Errors {
+		if err != nil && !errors.Is(err, kafka.TopicAlreadyExists) {
+			return fmt.Errorf("failed to create topic %s: %w", topic, err)
+		}
+	}
+
+	return nil
+}
+
+// Retry menulis salinan message ke topic <queue>.retry.N dengan key yang sama.
+// Pemanggil tetap harus Ack message aslinya agar offset-nya di-commit.
+func (s *kafkaService) Retry(ctx context.Context, queueName string, m Message, attempt int) error {
+	if attempt < 1 || attempt > len(RetryDelays) {
+		return fmt.Errorf("retry attempt %d out of range", attempt)
+	}
+
+	ack, ok := m.Acknowledger.(*kafkaAcknowledger)
+	if !ok {
+		return fmt.Errorf("message %s was not received from Kafka", m.ID)
+	}
+
+	writer, err := s.messageWriter()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withPublishTimeout(ctx)
+	defer cancel()
+
+	retry := kafkaCopy(ack.msg, kafkaRetryTopic(queueName, attempt), m.RoutingKey)
+	retry.Headers = append(retry.Headers, kafka.Header{Key: RetryCountHeader, Value: []byte(strconv.Itoa(attempt))})
+
+	if err := writer.WriteMessages(ctx, retry); err != nil {
+		return fmt.Errorf("failed to publish retry message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *kafkaService) SetupFailedQueue(ctx context.Context) (<-chan Message, error) {
+	return s.Consume(ctx, "order-service.order.failed", "order.failed")
+}
+
+// Close menghentikan semua reader lalu menutup writer setelah message yang
+// masih di-buffer terkirim.
+func (s *kafkaService) Close() error {
+	s.mu.Lock()
+	if s.state == StateClosed {
+		s.mu.Unlock()
+		return nil
+	}
+
+	writer := s.writer
+	s.state = StateClosed
+	close(s.done)
+	s.mu.Unlock()
+
+	if writer != nil {
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("failed to close Kafka writer: %w", err)
+		}
+	}
+
+	log.Println("Kafka connection closed")
+	return nil
+}
+
+// kafkaAcknowledger meng-commit offset saat Ack, menulis salinan ke topic DLQ
+// lalu commit saat Nack tanpa requeue, dan meminta reader mundur ke offset
+// terakhir yang sudah di-commit saat Nack dengan requeue.
+type kafkaAcknowledger struct {
+	committer kafkaCommitter
+	writer    kafkaWriter
+	queueName string
+	msg       kafka.Message
+	// settled menerima true jika message harus dibaca ulang.
+	settled chan bool
+	once    sync.Once
+}
+
+func newKafkaAcknowledger(committer kafkaCommitter, writer kafkaWriter, queueName string, msg kafka.Message) *kafkaAcknowledger {
+	return &kafkaAcknowledger{
+		committer: committer,
+		writer:    writer,
+		queueName: queueName,
+		msg:       msg,
+		settled:   make(chan bool, 1),
+	}
+}
+
+func (a *kafkaAcknowledger) settle(requeue bool) {
+	a.once.Do(func() { a.settled <- requeue })
+}
+
+func (a *kafkaAcknowledger) Ack() error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	err := a.committer.CommitMessages(ctx, a.msg)
+	a.settle(false)
+	if err != nil {
+		return fmt.Errorf("failed to commit offset: %w", err)
+	}
+
+	return nil
+}
+
+func (a *kafkaAcknowledger) Nack(requeue bool) error {
+	if requeue {
+		a.settle(true)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	dead := kafkaCopy(a.msg, kafkaDeadLetterTopic(a.queueName), "")
+	if err := a.writer.WriteMessages(ctx, dead); err != nil {
+		// Read the message again instead of committing past it without a DLQ copy
+		a.settle(true)
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+
+	return a.Ack()
+}
+
+// kafkaCopy menyalin msg ke topic lain. Routing key asal disimpan di header
+// agar consumer tetap melihat routing key yang sama untuk message retry.
+func kafkaCopy(msg kafka.Message, topic, routingKey string) kafka.Message {
+	if routingKey == "" {
+		routingKey = kafkaRoutingKey(msg)
+	}
+
+	headers := []kafka.Header{{Key: kafkaRoutingKeyHeader, Value: []byte(routingKey)}}
+	for _, h := range msg.Headers {
+		if h.Key != RetryCountHeader && h.Key != kafkaRoutingKeyHeader {
+			headers = append(headers, h)
+		}
+	}
+
+	return kafka.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
+
+func kafkaRoutingKey(msg kafka.Message) string {
+	for _, h := range msg.Headers {
+		if h.Key == kafkaRoutingKeyHeader {
+			return string(h.Value)
+		}
+	}
+
+	return msg.Topic
+}
+
+func newKafkaMessage(msg kafka.Message, ack Acknowledger) Message {
+	m := Message{
+		RoutingKey:   kafkaRoutingKey(msg),
+		Headers:      map[string]interface{}{},
+		Body:         msg.Value,
+		Acknowledger: ack,
+	}
+
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case kafkaMessageIDHeader:
+			m.ID = string(h.Value)
+		case kafkaContentTypeHeader:
+			m.ContentType = string(h.Value)
+		case RetryCountHeader:
+			if count, err := strconv.Atoi(string(h.Value)); err == nil {
+				m.Headers[h.Key] = count
+			}
+			continue
+		}
+
+		m.Headers[h.Key] = string(h.Value)
+	}
+
+	return m
+}
+
+func kafkaRetryTopic(queueName string, attempt int) string {
+	return retryQueueName(queueName, attempt)
+}
+
+func kafkaDeadLetterTopic(queueName string) string {
+	return queueName + ".dlq"
+}
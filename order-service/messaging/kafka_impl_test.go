@@ -0,0 +1,194 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKafkaWriter mencatat message yang ditulis dan offset yang di-commit.
+type fakeKafkaWriter struct {
+	written   []kafka.Message
+	committed []kafka.Message
+	err       error
+}
+
+func (w *fakeKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+func (w *fakeKafkaWriter) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.committed = append(w.committed, msgs...)
+	return nil
+}
+
+func (w *fakeKafkaWriter) Close() error {
+	return nil
+}
+
+func newTestKafkaService(w *fakeKafkaWriter) *kafkaService {
+	s := NewKafkaService()
+	s.writer = w
+	s.state = StateConnected
+
+	return s
+}
+
+func newFakeKafkaMessage() kafka.Message {
+	return kafka.Message{
+		Topic:  "order.created.request",
+		Key:    []byte("42"),
+		Value:  []byte(`{}`),
+		Offset: 7,
+		Headers: []kafka.Header{
+			{Key: kafkaMessageIDHeader, Value: []byte("msg-1")},
+			{Key: kafkaContentTypeHeader, Value: []byte("application/x-protobuf")},
+		},
+	}
+}
+
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+
+	return ""
+}
+
+func TestKafkaService_PublishEvent(t *testing.T) {
+	t.Run("should key messages by the partition key", func(t *testing.T) {
+		w := &fakeKafkaWriter{}
+		s := newTestKafkaService(w)
+
+		err := s.PublishEvent(context.Background(), "order_exchange", "order.created", "42", "msg-1", "", []byte(`{}`))
+
+		assert.NoError(t, err)
+		assert.Len(t, w.written, 1)
+		assert.Equal(t, "order.created", w.written[0].Topic)
+		assert.Equal(t, []byte("42"), w.written[0].Key)
+		assert.Equal(t, "msg-1", header(w.written[0], kafkaMessageIDHeader))
+		assert.Equal(t, "application/json", header(w.written[0], kafkaContentTypeHeader))
+	})
+
+	t.Run("should leave unkeyed messages to the balancer", func(t *testing.T) {
+		w := &fakeKafkaWriter{}
+		s := newTestKafkaService(w)
+
+		s.PublishEvent(context.Background(), "order_exchange", "order.failed", "", "msg-1", "", []byte(`{}`))
+
+		assert.Nil(t, w.written[0].Key)
+	})
+
+	t.Run("should fail before Connect", func(t *testing.T) {
+		err := NewKafkaService().PublishEvent(context.Background(), "", "order.created", "42", "msg-1", "", []byte(`{}`))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNewKafkaMessage(t *testing.T) {
+	t.Run("should map Kafka headers to the message", func(t *testing.T) {
+		msg := newFakeKafkaMessage()
+		msg.Headers = append(msg.Headers, kafka.Header{Key: RetryCountHeader, Value: []byte("2")})
+
+		m := newKafkaMessage(msg, nil)
+
+		assert.Equal(t, "msg-1", m.ID)
+		assert.Equal(t, "order.created.request", m.RoutingKey)
+		assert.Equal(t, "application/x-protobuf", m.ContentType)
+		assert.Equal(t, []byte(`{}`), m.Body)
+		assert.Equal(t, 2, RetryCount(m))
+	})
+
+	t.Run("should keep the original routing key of retried messages", func(t *testing.T) {
+		msg := kafkaCopy(newFakeKafkaMessage(), kafkaRetryTopic("orders", 1), "")
+
+		m := newKafkaMessage(msg, nil)
+
+		assert.Equal(t, "order.created.request", m.RoutingKey)
+		assert.Equal(t, []byte("42"), msg.Key)
+	})
+}
+
+func TestKafkaAcknowledger(t *testing.T) {
+	t.Run("should commit the offset only on ack", func(t *testing.T) {
+		w := &fakeKafkaWriter{}
+		ack := newKafkaAcknowledger(w, w, "orders", newFakeKafkaMessage())
+
+		assert.NoError(t, ack.Ack())
+
+		assert.Len(t, w.committed, 1)
+		assert.Equal(t, int64(7), w.committed[0].Offset)
+		assert.False(t, <-ack.settled)
+	})
+
+	t.Run("should rewind without committing on requeue", func(t *testing.T) {
+		w := &fakeKafkaWriter{}
+		ack := newKafkaAcknowledger(w, w, "orders", newFakeKafkaMessage())
+
+		assert.NoError(t, ack.Nack(true))
+
+		assert.Empty(t, w.committed)
+		assert.True(t, <-ack.settled)
+	})
+
+	t.Run("should copy rejected messages to the DLQ before committing", func(t *testing.T) {
+		w := &fakeKafkaWriter{}
+		ack := newKafkaAcknowledger(w, w, "orders", newFakeKafkaMessage())
+
+		assert.NoError(t, ack.Nack(false))
+
+		assert.Len(t, w.written, 1)
+		assert.Equal(t, "orders.dlq", w.written[0].Topic)
+		assert.Equal(t, []byte("42"), w.written[0].Key)
+		assert.Len(t, w.committed, 1)
+		assert.False(t, <-ack.settled)
+	})
+
+	t.Run("should keep the message when the DLQ copy fails", func(t *testing.T) {
+		w := &fakeKafkaWriter{err: errors.New("broker unavailable")}
+		ack := newKafkaAcknowledger(w, w, "orders", newFakeKafkaMessage())
+
+		assert.Error(t, ack.Nack(false))
+
+		assert.Empty(t, w.committed)
+		assert.True(t, <-ack.settled)
+	})
+}
+
+func TestKafkaService_Retry(t *testing.T) {
+	t.Run("should publish to the retry topic of the attempt with the same key", func(t *testing.T) {
+		w := &fakeKafkaWriter{}
+		s := newTestKafkaService(w)
+		msg := newFakeKafkaMessage()
+		m := newKafkaMessage(msg, newKafkaAcknowledger(w, w, "orders", msg))
+
+		assert.NoError(t, s.Retry(context.Background(), "orders", m, 2))
+
+		assert.Len(t, w.written, 1)
+		assert.Equal(t, kafkaRetryTopic("orders", 2), w.written[0].Topic)
+		assert.Equal(t, []byte("42"), w.written[0].Key)
+		assert.Equal(t, "2", header(w.written[0], RetryCountHeader))
+
+		// Expect the original offset to stay uncommitted until the caller acks
+		assert.Empty(t, w.committed)
+	})
+
+	t.Run("should reject messages from another broker", func(t *testing.T) {
+		s := newTestKafkaService(&fakeKafkaWriter{})
+
+		err := s.Retry(context.Background(), "orders", Message{ID: "msg-1", Acknowledger: &memoryAcknowledger{}}, 1)
+
+		assert.Error(t, err)
+	})
+}
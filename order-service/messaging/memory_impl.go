@@ -0,0 +1,313 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// memoryService adalah broker in-process untuk test dan development lokal
+// tanpa RabbitMQ. Routing mengikuti direct exchange: message dikirim ke setiap
+// queue yang di-bind dengan routing key yang sama. Message hilang saat proses
+// berhenti.
+type memoryService struct {
+	mu    sync.Mutex
+	state ConnectionState
+	// bindings memetakan exchange -> routing key -> nama queue.
+	bindings map[string]map[string][]string
+	queues   map[string]*memoryQueue
+	done     chan struct{}
+	// retryDelays bisa dipersingkat oleh test.
+	retryDelays []time.Duration
+}
+
+func NewMemoryService() *memoryService {
+	return &memoryService{
+		state:       StateDisconnected,
+		bindings:    map[string]map[string][]string{},
+		queues:      map[string]*memoryQueue{},
+		done:        make(chan struct{}),
+		retryDelays: RetryDelays,
+	}
+}
+
+func (s *memoryService) Connect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == StateClosed {
+		return fmt.Errorf("in-memory broker is closed")
+	}
+
+	s.state = StateConnected
+	return nil
+}
+
+func (s *memoryService) State() ConnectionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state
+}
+
+func (s *memoryService) PublishEvent(ctx context.Context, exchangeName, routingKey, partitionKey, messageID, contentType string, body []byte) error {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	s.mu.Lock()
+	if s.state != StateConnected {
+		s.mu.Unlock()
+		return fmt.Errorf("in-memory broker is not connected")
+	}
+
+	var queues []*memoryQueue
+	for _, name := range s.bindings[exchangeName][routingKey] {
+		queues = append(queues, s.queues[name])
+	}
+	s.mu.Unlock()
+
+	// Like a non-mandatory AMQP publish, unroutable messages are dropped
+	for _, q := range queues {
+		q.push(Message{
+			ID:          messageID,
+			RoutingKey:  routingKey,
+			ContentType: contentType,
+			Body:        append([]byte(nil), body...),
+		})
+	}
+
+	return nil
+}
+
+func (s *memoryService) Consume(ctx context.Context, queueName string, routingKey string) (<-chan Message, error) {
+	return s.ConsumeExchange(ctx, os.Getenv("RABBITMQ_EXCHANGE_NAME"), queueName, routingKey)
+}
+
+// ConsumeExchange mendeklarasikan queue, mem-bind routing key ke exchange,
+// lalu mengirim message ke channel sampai ctx dibatalkan atau broker ditutup.
+// Message yang belum diterima consumer saat berhenti dikembalikan ke queue.
+func (s *memoryService) ConsumeExchange(ctx context.Context, exchangeName, queueName string, routingKeys ...string) (<-chan Message, error) {
+	q, err := s.declare(exchangeName, queueName, routingKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan Message)
+
+	go func() {
+		defer close(out)
+
+		for {
+			m, ok := q.pop()
+			if !ok {
+				select {
+				case <-q.signal:
+					continue
+				case <-ctx.Done():
+					return
+				case <-s.done:
+					return
+				}
+			}
+
+			m.Acknowledger = &memoryAcknowledger{queue: q, message: m}
+
+			select {
+			case out <- m:
+			case <-ctx.Done():
+				q.push(m)
+				return
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Bind mendeklarasikan queue dan binding-nya tanpa memulai consumer, agar
+// message yang dipublish sebelum consumer berjalan tetap tersimpan.
+func (s *memoryService) Bind(exchangeName, queueName string, routingKeys ...string) error {
+	_, err := s.declare(exchangeName, queueName, routingKeys)
+	return err
+}
+
+func (s *memoryService) declare(exchangeName, queueName string, routingKeys []string) (*memoryQueue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != StateConnected {
+		return nil, fmt.Errorf("in-memory broker is not connected")
+	}
+
+	q, ok := s.queues[queueName]
+	if !ok {
+		q = newMemoryQueue()
+		s.queues[queueName] = q
+	}
+
+	if s.bindings[exchangeName] == nil {
+		s.bindings[exchangeName] = map[string][]string{}
+	}
+
+	for _, routingKey := range routingKeys {
+		if !containsString(s.bindings[exchangeName][routingKey], queueName) {
+			s.bindings[exchangeName][routingKey] = append(s.bindings[exchangeName][routingKey], queueName)
+		}
+	}
+
+	return q, nil
+}
+
+// Retry mengirim ulang message ke queueName setelah jeda untuk attempt
+// tersebut. Pemanggil tetap harus Ack message aslinya setelah Retry berhasil.
+func (s *memoryService) Retry(ctx context.Context, queueName string, m Message, attempt int) error {
+	if attempt < 1 || attempt > len(s.retryDelays) {
+		return fmt.Errorf("retry attempt %d out of range", attempt)
+	}
+
+	s.mu.Lock()
+	q, ok := s.queues[queueName]
+	s.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("queue %s is not declared", queueName)
+	}
+
+	headers := map[string]interface{}{}
+	for k, v := range m.Headers {
+		headers[k] = v
+	}
+	headers[RetryCountHeader] = attempt
+
+	retry := Message{
+		ID:          m.ID,
+		RoutingKey:  m.RoutingKey,
+		ContentType: m.ContentType,
+		Headers:     headers,
+		Body:        m.Body,
+	}
+
+	time.AfterFunc(s.retryDelays[attempt-1], func() {
+		select {
+		case <-s.done:
+		default:
+			q.push(retry)
+		}
+	})
+
+	return nil
+}
+
+func (s *memoryService) SetupFailedQueue(ctx context.Context) (<-chan Message, error) {
+	return s.Consume(ctx, "order-service.order.failed", "order.failed")
+}
+
+// DeadLetters mengembalikan message yang di-Nack tanpa requeue dari queueName.
+func (s *memoryService) DeadLetters(queueName string) []Message {
+	s.mu.Lock()
+	q, ok := s.queues[queueName]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return append([]Message(nil), q.dead...)
+}
+
+func (s *memoryService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == StateClosed {
+		return nil
+	}
+
+	s.state = StateClosed
+	close(s.done)
+	return nil
+}
+
+type memoryQueue struct {
+	mu      sync.Mutex
+	pending []Message
+	dead    []Message
+	// signal membangunkan consumer yang menunggu message baru.
+	signal chan struct{}
+}
+
+func newMemoryQueue() *memoryQueue {
+	return &memoryQueue{signal: make(chan struct{}, 1)}
+}
+
+func (q *memoryQueue) push(m Message) {
+	m.Acknowledger = nil
+
+	q.mu.Lock()
+	q.pending = append(q.pending, m)
+	q.mu.Unlock()
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (q *memoryQueue) pop() (Message, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return Message{}, false
+	}
+
+	m := q.pending[0]
+	q.pending = q.pending[1:]
+	return m, true
+}
+
+func (q *memoryQueue) deadLetter(m Message) {
+	m.Acknowledger = nil
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.dead = append(q.dead, m)
+}
+
+type memoryAcknowledger struct {
+	queue   *memoryQueue
+	message Message
+}
+
+func (a *memoryAcknowledger) Ack() error {
+	return nil
+}
+
+func (a *memoryAcknowledger) Nack(requeue bool) error {
+	if requeue {
+		a.queue.push(a.message)
+	} else {
+		a.queue.deadLetter(a.message)
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,130 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMemoryService(t *testing.T) *memoryService {
+	s := NewMemoryService()
+	s.retryDelays = []time.Duration{time.Millisecond, time.Millisecond}
+	assert.NoError(t, s.Connect())
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func receive(t *testing.T, msgs <-chan Message) Message {
+	t.Helper()
+
+	select {
+	case m := <-msgs:
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return Message{}
+	}
+}
+
+func TestMemoryService_PublishEvent(t *testing.T) {
+	t.Run("should route messages to every queue bound to the routing key", func(t *testing.T) {
+		s := newTestMemoryService(t)
+		ctx := context.Background()
+
+		first, _ := s.ConsumeExchange(ctx, "order_exchange", "first", "order.created")
+		second, _ := s.ConsumeExchange(ctx, "order_exchange", "second", "order.created", "order.failed")
+
+		assert.NoError(t, s.PublishEvent(ctx, "order_exchange", "order.created", "", "msg-1", "", []byte(`{}`)))
+		assert.NoError(t, s.PublishEvent(ctx, "order_exchange", "order.failed", "", "msg-2", "application/x-protobuf", []byte(`{}`)))
+
+		m := receive(t, first)
+		assert.Equal(t, "msg-1", m.ID)
+		assert.Equal(t, "application/json", m.ContentType)
+
+		assert.Equal(t, "msg-1", receive(t, second).ID)
+		m = receive(t, second)
+		assert.Equal(t, "msg-2", m.ID)
+		assert.Equal(t, "application/x-protobuf", m.ContentType)
+	})
+
+	t.Run("should fail when the broker is closed", func(t *testing.T) {
+		s := newTestMemoryService(t)
+		s.Close()
+
+		err := s.PublishEvent(context.Background(), "order_exchange", "order.created", "", "msg-1", "", []byte(`{}`))
+
+		assert.Error(t, err)
+		assert.Equal(t, StateClosed, s.State())
+	})
+}
+
+func TestMemoryService_Acknowledger(t *testing.T) {
+	t.Run("should redeliver requeued messages and park rejected ones", func(t *testing.T) {
+		s := newTestMemoryService(t)
+		ctx := context.Background()
+
+		msgs, _ := s.Consume(ctx, "orders", "order.created")
+		s.PublishEvent(ctx, "", "order.created", "", "msg-1", "", []byte(`{}`))
+
+		m := receive(t, msgs)
+		assert.NoError(t, m.Nack(true))
+
+		m = receive(t, msgs)
+		assert.Equal(t, "msg-1", m.ID)
+		assert.NoError(t, m.Nack(false))
+
+		dead := s.DeadLetters("orders")
+		assert.Len(t, dead, 1)
+		assert.Equal(t, "msg-1", dead[0].ID)
+	})
+}
+
+func TestMemoryService_Retry(t *testing.T) {
+	t.Run("should redeliver the message with the retry count", func(t *testing.T) {
+		s := newTestMemoryService(t)
+		ctx := context.Background()
+
+		msgs, _ := s.Consume(ctx, "orders", "order.created")
+		s.PublishEvent(ctx, "", "order.created", "", "msg-1", "", []byte(`{"a":1}`))
+
+		m := receive(t, msgs)
+		assert.NoError(t, s.Retry(ctx, "orders", m, 1))
+		m.Ack()
+
+		m = receive(t, msgs)
+		assert.Equal(t, "msg-1", m.ID)
+		assert.Equal(t, []byte(`{"a":1}`), m.Body)
+		assert.Equal(t, 1, RetryCount(m))
+	})
+
+	t.Run("should reject attempts beyond the configured delays", func(t *testing.T) {
+		s := newTestMemoryService(t)
+		s.Bind("", "orders", "order.created")
+
+		err := s.Retry(context.Background(), "orders", Message{ID: "msg-1"}, 3)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMemoryService_ConsumeExchange(t *testing.T) {
+	t.Run("should keep undelivered messages when the consumer stops", func(t *testing.T) {
+		s := newTestMemoryService(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		msgs, _ := s.ConsumeExchange(ctx, "order_exchange", "orders", "order.created")
+		cancel()
+
+		// Expect the delivery channel to be closed
+		_, ok := <-msgs
+		assert.False(t, ok)
+
+		s.PublishEvent(context.Background(), "order_exchange", "order.created", "", "msg-1", "", []byte(`{}`))
+
+		msgs, _ = s.ConsumeExchange(context.Background(), "order_exchange", "orders", "order.created")
+		assert.Equal(t, "msg-1", receive(t, msgs).ID)
+	})
+}
@@ -0,0 +1,105 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+type ConnectionState string
+
+const (
+	StateDisconnected ConnectionState = "disconnected"
+	StateConnecting   ConnectionState = "connecting"
+	StateConnected    ConnectionState = "connected"
+	StateClosed       ConnectionState = "closed"
+)
+
+const RetryCountHeader = "x-retry-count"
+
+// RetryDelays adalah jeda sebelum tiap percobaan ulang. Setelah semua
+// percobaan habis, message diparkir di DLQ.
+var RetryDelays = []time.Duration{5 * time.Second, 30 * time.Second, 2 * time.Minute}
+
+// MessagingService adalah kontrak broker yang dipakai service. Implementasinya
+// ada untuk RabbitMQ, NATS JetStream dan broker in-memory, dipilih lewat
+// MESSAGE_BROKER. partitionKey di PublishEvent hanya dipakai broker yang
+// mempartisi message (Kafka) dan diabaikan oleh yang lain.
+type MessagingService interface {
+	Connect() error
+	State() ConnectionState
+	PublishEvent(ctx context.Context, exchangeName, routingKey, partitionKey, messageID, contentType string, body []byte) error
+	Consume(ctx context.Context, queueName string, routingKey string) (<-chan Message, error)
+	ConsumeExchange(ctx context.Context, exchangeName, queueName string, routingKeys ...string) (<-chan Message, error)
+	Retry(ctx context.Context, queueName string, m Message, attempt int) error
+	SetupFailedQueue(ctx context.Context) (<-chan Message, error)
+	Close() error
+}
+
+// BrokerName mengembalikan nama broker dari MESSAGE_BROKER, atau rabbitmq
+// jika kosong.
+func BrokerName() string {
+	if broker := os.Getenv("MESSAGE_BROKER"); broker != "" {
+		return broker
+	}
+
+	return "rabbitmq"
+}
+
+// NewMessagingService membuat implementasi broker sesuai MESSAGE_BROKER:
+// rabbitmq (default), nats, kafka atau memory.
+func NewMessagingService() (MessagingService, error) {
+	switch broker := BrokerName(); broker {
+	case "rabbitmq":
+		return NewRabbitMQService(), nil
+	case "nats":
+		return NewNATSService(), nil
+	case "kafka":
+		return NewKafkaService(), nil
+	case "memory":
+		return NewMemoryService(), nil
+	default:
+		return nil, fmt.Errorf("unsupported MESSAGE_BROKER %q", broker)
+	}
+}
+
+// Acknowledger menyelesaikan message di broker asalnya. Nack tanpa requeue
+// memarkir message di DLQ.
+type Acknowledger interface {
+	Ack() error
+	Nack(requeue bool) error
+}
+
+// Message adalah message yang diterima consumer tanpa bergantung pada tipe
+// milik broker tertentu.
+type Message struct {
+	ID           string
+	RoutingKey   string
+	ContentType  string
+	Headers      map[string]interface{}
+	Body         []byte
+	Acknowledger Acknowledger
+}
+
+func (m Message) Ack() error {
+	return m.Acknowledger.Ack()
+}
+
+func (m Message) Nack(requeue bool) error {
+	return m.Acknowledger.Nack(requeue)
+}
+
+// RetryCount membaca header x-retry-count dari message.
+func RetryCount(m Message) int {
+	switch v := m.Headers[RetryCountHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+
+	return 0
+}
@@ -0,0 +1,405 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	natsAckWait = 30 * time.Second
+
+	natsContentTypeHeader   = "Content-Type"
+	natsOriginalMsgIDHeader = "Original-Msg-Id"
+)
+
+var natsNameReplacer = strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_", "/", "_", "\\", "_")
+
+// natsService mengimplementasikan MessagingService di atas NATS JetStream.
+// Setiap exchange menjadi stream dengan subject "<exchange>.>", routing key
+// menjadi subject "<exchange>.<routingKey>" dan setiap queue menjadi durable
+// consumer. Message yang di-Nack tanpa requeue disalin ke subject
+// "<exchange>.dlq.<queue>" lalu di-Term.
+type natsService struct {
+	url  string
+	conn *nats.Conn
+	js   jetstream.JetStream
+	// streams berisi stream yang sudah dideklarasikan oleh proses ini.
+	streams map[string]bool
+	closed  bool
+	mu      sync.RWMutex
+}
+
+func NewNATSService() *natsService {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		url = nats.DefaultURL
+	}
+
+	return &natsService{
+		url:     url,
+		streams: map[string]bool{},
+	}
+}
+
+// Connect membuka koneksi ke NATS. Reconnect ditangani oleh client NATS dan
+// consumer JetStream melanjutkan fetch setelah koneksi pulih.
+func (s *natsService) Connect() error {
+	conn, err := nats.Connect(s.url,
+		nats.Name("order-service"),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(reconnectInitialDelay),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Printf("NATS connection lost: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			log.Println("NATS connection re-established!")
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	s.mu.Lock()
+	s.conn = conn
+	s.js = js
+	s.mu.Unlock()
+
+	log.Println("NATS connection successfully opened!")
+	return nil
+}
+
+func (s *natsService) State() ConnectionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return StateClosed
+	}
+
+	if s.conn == nil {
+		return StateDisconnected
+	}
+
+	return natsConnectionState(s.conn.Status())
+}
+
+func natsConnectionState(status nats.Status) ConnectionState {
+	switch status {
+	case nats.CONNECTED:
+		return StateConnected
+	case nats.CONNECTING, nats.RECONNECTING:
+		return StateConnecting
+	case nats.CLOSED:
+		return StateClosed
+	}
+
+	return StateDisconnected
+}
+
+func (s *natsService) jetStream() (jetstream.JetStream, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.js == nil || s.closed {
+		return nil, fmt.Errorf("NATS connection is not established")
+	}
+
+	return s.js, nil
+}
+
+// ensureStream membuat stream untuk exchange bila belum dideklarasikan dan
+// mengembalikan namanya.
+func (s *natsService) ensureStream(ctx context.Context, js jetstream.JetStream, exchangeName string) (string, error) {
+	name := natsStreamName(exchangeName)
+
+	s.mu.RLock()
+	declared := s.streams[name]
+	s.mu.RUnlock()
+
+	if declared {
+		return name, nil
+	}
+
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     name,
+		Subjects: []string{exchangeName + ".>"},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to declare stream %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	s.streams[name] = true
+	s.mu.Unlock()
+
+	return name, nil
+}
+
+// PublishEvent mengirim message ke JetStream dan baru kembali setelah stream
+// mengonfirmasinya. messageID dikirim sebagai Nats-Msg-Id sehingga publish
+// ulang oleh outbox relay dibuang oleh deduplikasi JetStream.
+func (s *natsService) PublishEvent(ctx context.Context, exchangeName, routingKey, partitionKey, messageID, contentType string, body []byte) error {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	js, err := s.jetStream()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withPublishTimeout(ctx)
+	defer cancel()
+
+	if _, err := s.ensureStream(ctx, js, exchangeName); err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(natsSubject(exchangeName, routingKey))
+	msg.Header.Set(natsContentTypeHeader, contentType)
+	msg.Data = body
+
+	if _, err := js.PublishMsg(ctx, msg, jetstream.WithMsgID(messageID)); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	return nil
+}
+
+func (s *natsService) Consume(ctx context.Context, queueName string, routingKey string) (<-chan Message, error) {
+	return s.ConsumeExchange(ctx, os.Getenv("RABBITMQ_EXCHANGE_NAME"), queueName, routingKey)
+}
+
+// ConsumeExchange membuat (atau memperbarui) durable consumer bernama
+// queueName yang memfilter subject routing key, lalu mengirim message ke
+// channel sampai ctx dibatalkan atau koneksi ditutup. Message yang belum di-Ack
+// dikirim ulang oleh JetStream setelah AckWait.
+func (s *natsService) ConsumeExchange(ctx context.Context, exchangeName, queueName string, routingKeys ...string) (<-chan Message, error) {
+	js, err := s.jetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := s.ensureStream(ctx, js, exchangeName)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := make([]string, 0, len(routingKeys))
+	for _, routingKey := range routingKeys {
+		subjects = append(subjects, natsSubject(exchangeName, routingKey))
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:        natsConsumerName(queueName),
+		FilterSubjects: subjects,
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        natsAckWait,
+		MaxAckPending:  consumerPrefetchCount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to declare consumer %s: %w", queueName, err)
+	}
+
+	iter, err := consumer.Messages(jetstream.PullMaxMessages(consumerPrefetchCount))
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume %s: %w", queueName, err)
+	}
+
+	deadLetter := func(msg jetstream.Msg) error {
+		return s.publishDeadLetter(exchangeName, queueName, msg)
+	}
+
+	out := make(chan Message)
+
+	go func() {
+		defer close(out)
+
+		// Unblock Next once the consumer is cancelled
+		stop := context.AfterFunc(ctx, iter.Stop)
+		defer stop()
+
+		for {
+			msg, err := iter.Next()
+			if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+				return
+			}
+			if err != nil {
+				log.Printf("Consumer for queue %s failed to fetch messages: %v", queueName, err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(reconnectInitialDelay):
+				}
+				continue
+			}
+
+			select {
+			case out <- newNATSMessage(exchangeName, msg, deadLetter):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// publishDeadLetter menyalin msg ke subject DLQ milik queueName. Nats-Msg-Id
+// tidak ikut disalin agar salinannya tidak dibuang sebagai duplikat.
+func (s *natsService) publishDeadLetter(exchangeName, queueName string, msg jetstream.Msg) error {
+	js, err := s.jetStream()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	dead := nats.NewMsg(natsSubject(exchangeName, "dlq."+queueName))
+	for k, v := range msg.Headers() {
+		dead.Header[k] = v
+	}
+	dead.Header.Del(jetstream.MsgIDHeader)
+	dead.Header.Set(natsOriginalMsgIDHeader, msg.Headers().Get(jetstream.MsgIDHeader))
+	dead.Data = msg.Data()
+
+	if _, err := js.PublishMsg(ctx, dead); err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+
+	return nil
+}
+
+// Retry meminta JetStream mengirim ulang message setelah jeda untuk attempt
+// tersebut (NakWithDelay). Ack berikutnya dari pemanggil diabaikan karena
+// message sudah diselesaikan oleh Retry.
+func (s *natsService) Retry(ctx context.Context, queueName string, m Message, attempt int) error {
+	if attempt < 1 || attempt > len(RetryDelays) {
+		return fmt.Errorf("retry attempt %d out of range", attempt)
+	}
+
+	ack, ok := m.Acknowledger.(*natsAcknowledger)
+	if !ok {
+		return fmt.Errorf("message %s was not received from NATS", m.ID)
+	}
+
+	if err := ack.msg.NakWithDelay(RetryDelays[attempt-1]); err != nil {
+		return fmt.Errorf("failed to schedule retry: %w", err)
+	}
+
+	ack.retried = true
+	return nil
+}
+
+func (s *natsService) SetupFailedQueue(ctx context.Context) (<-chan Message, error) {
+	return s.Consume(ctx, "order-service.order.failed", "order.failed")
+}
+
+// Close menguras subscription yang aktif lalu menutup koneksi ke NATS.
+func (s *natsService) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+
+	conn := s.conn
+	s.closed = true
+	s.mu.Unlock()
+
+	if conn != nil && !conn.IsClosed() {
+		if err := conn.Drain(); err != nil {
+			return fmt.Errorf("failed to drain NATS connection: %w", err)
+		}
+	}
+
+	log.Println("NATS connection closed")
+	return nil
+}
+
+// natsAcknowledger memetakan Ack ke Ack, Nack dengan requeue ke Nak, dan Nack
+// tanpa requeue ke salinan DLQ diikuti Term.
+type natsAcknowledger struct {
+	msg        jetstream.Msg
+	deadLetter func(jetstream.Msg) error
+	// retried diisi oleh Retry setelah NakWithDelay berhasil.
+	retried bool
+}
+
+func (a *natsAcknowledger) Ack() error {
+	if a.retried {
+		return nil
+	}
+
+	return a.msg.Ack()
+}
+
+func (a *natsAcknowledger) Nack(requeue bool) error {
+	if requeue {
+		return a.msg.Nak()
+	}
+
+	if err := a.deadLetter(a.msg); err != nil {
+		// Keep the message instead of dropping it without a DLQ copy
+		if nakErr := a.msg.Nak(); nakErr != nil {
+			return errors.Join(err, nakErr)
+		}
+		return err
+	}
+
+	return a.msg.TermWithReason("dead-lettered")
+}
+
+// newNATSMessage mengubah message JetStream ke Message. Jumlah retry diambil
+// dari NumDelivered, sehingga setiap redelivery dihitung sebagai satu retry.
+func newNATSMessage(exchangeName string, msg jetstream.Msg, deadLetter func(jetstream.Msg) error) Message {
+	headers := map[string]interface{}{}
+	for k := range msg.Headers() {
+		headers[k] = msg.Headers().Get(k)
+	}
+
+	if meta, err := msg.Metadata(); err == nil && meta.NumDelivered > 1 {
+		headers[RetryCountHeader] = int(meta.NumDelivered - 1)
+	}
+
+	return Message{
+		ID:           msg.Headers().Get(jetstream.MsgIDHeader),
+		RoutingKey:   strings.TrimPrefix(msg.Subject(), exchangeName+"."),
+		ContentType:  msg.Headers().Get(natsContentTypeHeader),
+		Headers:      headers,
+		Body:         msg.Data(),
+		Acknowledger: &natsAcknowledger{msg: msg, deadLetter: deadLetter},
+	}
+}
+
+func natsSubject(exchangeName, routingKey string) string {
+	return exchangeName + "." + routingKey
+}
+
+func natsStreamName(exchangeName string) string {
+	return natsNameReplacer.Replace(exchangeName)
+}
+
+func natsConsumerName(queueName string) string {
+	return natsNameReplacer.Replace(queueName)
+}
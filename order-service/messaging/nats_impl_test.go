@@ -0,0 +1,167 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeJetStreamMsg mencatat bagaimana message JetStream diselesaikan.
+type fakeJetStreamMsg struct {
+	subject      string
+	headers      nats.Header
+	data         []byte
+	numDelivered uint64
+
+	acked      bool
+	naked      bool
+	nakDelay   time.Duration
+	terminated bool
+}
+
+func (m *fakeJetStreamMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{NumDelivered: m.numDelivered}, nil
+}
+
+func (m *fakeJetStreamMsg) Data() []byte         { return m.data }
+func (m *fakeJetStreamMsg) Headers() nats.Header { return m.headers }
+func (m *fakeJetStreamMsg) Subject() string      { return m.subject }
+func (m *fakeJetStreamMsg) Reply() string        { return "" }
+func (m *fakeJetStreamMsg) InProgress() error    { return nil }
+
+func (m *fakeJetStreamMsg) Ack() error {
+	m.acked = true
+	return nil
+}
+
+func (m *fakeJetStreamMsg) DoubleAck(context.Context) error {
+	return m.Ack()
+}
+
+func (m *fakeJetStreamMsg) Nak() error {
+	m.naked = true
+	return nil
+}
+
+func (m *fakeJetStreamMsg) NakWithDelay(delay time.Duration) error {
+	m.nakDelay = delay
+	return m.Nak()
+}
+
+func (m *fakeJetStreamMsg) Term() error {
+	m.terminated = true
+	return nil
+}
+
+func (m *fakeJetStreamMsg) TermWithReason(string) error {
+	return m.Term()
+}
+
+func newFakeJetStreamMsg(numDelivered uint64) *fakeJetStreamMsg {
+	headers := nats.Header{}
+	headers.Set(jetstream.MsgIDHeader, "msg-1")
+	headers.Set(natsContentTypeHeader, "application/x-protobuf")
+
+	return &fakeJetStreamMsg{
+		subject:      "order_exchange.order.created.request",
+		headers:      headers,
+		data:         []byte(`{}`),
+		numDelivered: numDelivered,
+	}
+}
+
+func TestNewNATSMessage(t *testing.T) {
+	t.Run("should map JetStream metadata to the message", func(t *testing.T) {
+		msg := newFakeJetStreamMsg(3)
+
+		m := newNATSMessage("order_exchange", msg, nil)
+
+		assert.Equal(t, "msg-1", m.ID)
+		assert.Equal(t, "order.created.request", m.RoutingKey)
+		assert.Equal(t, "application/x-protobuf", m.ContentType)
+		assert.Equal(t, []byte(`{}`), m.Body)
+
+		// Expect every redelivery to count as a retry
+		assert.Equal(t, 2, RetryCount(m))
+	})
+
+	t.Run("should not count the first delivery as a retry", func(t *testing.T) {
+		m := newNATSMessage("order_exchange", newFakeJetStreamMsg(1), nil)
+
+		assert.Equal(t, 0, RetryCount(m))
+	})
+}
+
+func TestNATSAcknowledger(t *testing.T) {
+	t.Run("should ack and nak like the RabbitMQ delivery", func(t *testing.T) {
+		acked := newFakeJetStreamMsg(1)
+		assert.NoError(t, newNATSMessage("order_exchange", acked, nil).Ack())
+		assert.True(t, acked.acked)
+
+		requeued := newFakeJetStreamMsg(1)
+		assert.NoError(t, newNATSMessage("order_exchange", requeued, nil).Nack(true))
+		assert.True(t, requeued.naked)
+		assert.False(t, requeued.terminated)
+	})
+
+	t.Run("should copy rejected messages to the DLQ before terminating them", func(t *testing.T) {
+		msg := newFakeJetStreamMsg(1)
+		var deadLettered jetstream.Msg
+
+		m := newNATSMessage("order_exchange", msg, func(msg jetstream.Msg) error {
+			deadLettered = msg
+			return nil
+		})
+
+		assert.NoError(t, m.Nack(false))
+		assert.Equal(t, msg, deadLettered)
+		assert.True(t, msg.terminated)
+	})
+
+	t.Run("should keep the message when the DLQ copy fails", func(t *testing.T) {
+		msg := newFakeJetStreamMsg(1)
+
+		m := newNATSMessage("order_exchange", msg, func(jetstream.Msg) error {
+			return errors.New("stream unavailable")
+		})
+
+		assert.Error(t, m.Nack(false))
+		assert.True(t, msg.naked)
+		assert.False(t, msg.terminated)
+	})
+}
+
+func TestNATSService_Retry(t *testing.T) {
+	t.Run("should nak with the delay of the attempt and ignore the following ack", func(t *testing.T) {
+		s := NewNATSService()
+		msg := newFakeJetStreamMsg(1)
+		m := newNATSMessage("order_exchange", msg, nil)
+
+		assert.NoError(t, s.Retry(context.Background(), "orders", m, 2))
+		assert.NoError(t, m.Ack())
+
+		assert.Equal(t, RetryDelays[1], msg.nakDelay)
+		assert.False(t, msg.acked)
+	})
+
+	t.Run("should reject messages from another broker", func(t *testing.T) {
+		s := NewNATSService()
+
+		err := s.Retry(context.Background(), "orders", Message{ID: "msg-1", Acknowledger: &memoryAcknowledger{}}, 1)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNATSNames(t *testing.T) {
+	assert.Equal(t, "order_exchange", natsStreamName("order_exchange"))
+	assert.Equal(t, "order-service_order_created_request", natsConsumerName("order-service.order.created.request"))
+	assert.Equal(t, "order_exchange.order.failed", natsSubject("order_exchange", "order.failed"))
+	assert.Equal(t, StateConnecting, natsConnectionState(nats.RECONNECTING))
+	assert.Equal(t, StateDisconnected, natsConnectionState(nats.DRAINING_PUBS))
+}
@@ -5,45 +5,243 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/rabbitmq/amqp091-go"
 )
 
-type messagingService struct {
-	conn *amqp091.Connection
-	mu   sync.Mutex
+const (
+	reconnectInitialDelay = time.Second
+	reconnectMaxDelay     = 30 * time.Second
+	consumerPrefetchCount = 10
+	publishTimeout        = 5 * time.Second
+
+	defaultPublisherPoolSize = 32
+)
+
+type rabbitMQService struct {
+	uri   string
+	conn  *amqp091.Connection
+	state ConnectionState
+	// ready ditutup saat koneksi tersedia dan diganti saat koneksi terputus,
+	// sehingga consumer bisa menunggu reconnect tanpa polling.
+	ready chan struct{}
+	// done ditutup oleh Close untuk menghentikan reconnect dan consumer.
+	done chan struct{}
+	// publishers menyimpan channel idle dalam confirm mode untuk PublishEvent.
+	publishers chan *amqp091.Channel
+	mu         sync.RWMutex
 }
 
-func NewRabbitMQService() *messagingService {
-	return &messagingService{}
+func NewRabbitMQService() *rabbitMQService {
+	return &rabbitMQService{
+		uri: fmt.Sprintf("amqp://%s:%s@%s:%s/",
+			os.Getenv("RABBITMQ_USER"),
+			os.Getenv("RABBITMQ_PASSWORD"),
+			os.Getenv("RABBITMQ_HOST"),
+			os.Getenv("RABBITMQ_PORT"),
+		),
+		state:      StateDisconnected,
+		ready:      make(chan struct{}),
+		done:       make(chan struct{}),
+		publishers: make(chan *amqp091.Channel, publisherPoolSize()),
+	}
 }
 
-func (s *messagingService) ConnectRabbitMQ() error {
-	amqpURI := fmt.Sprintf("amqp://%s:%s@%s:%s/",
-		os.Getenv("RABBITMQ_USER"),
-		os.Getenv("RABBITMQ_PASSWORD"),
-		os.Getenv("RABBITMQ_HOST"),
-		os.Getenv("RABBITMQ_PORT"),
-	)
+func publisherPoolSize() int {
+	size, err := strconv.Atoi(os.Getenv("RABBITMQ_PUBLISHER_POOL_SIZE"))
+	if err != nil || size <= 0 {
+		return defaultPublisherPoolSize
+	}
 
-	var err error
-	s.conn, err = amqp091.Dial(amqpURI)
+	return size
+}
+
+// Connect membuka koneksi pertama lalu menjalankan supervisor yang
+// melakukan reconnect otomatis ketika koneksi ke broker terputus.
+func (s *rabbitMQService) Connect() error {
+	s.setState(StateConnecting)
+
+	conn, err := amqp091.Dial(s.uri)
 	if err != nil {
+		s.setState(StateDisconnected)
 		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
+	s.setConnection(conn)
 	log.Println("RabbitMQ connection successfully opened!")
+
+	go s.supervise(conn)
+
 	return nil
 }
 
-func (s *messagingService) GetChannel() (*amqp091.Channel, error) {
-	if s.conn == nil {
+func (s *rabbitMQService) State() ConnectionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state
+}
+
+func (s *rabbitMQService) supervise(conn *amqp091.Connection) {
+	for {
+		closeErr := <-conn.NotifyClose(make(chan *amqp091.Error, 1))
+		if closeErr == nil {
+			// Connection closed by the application
+			return
+		}
+
+		log.Printf("RabbitMQ connection lost: %v", closeErr)
+		s.markDisconnected()
+
+		conn = s.reconnect()
+		if conn == nil {
+			return
+		}
+	}
+}
+
+func (s *rabbitMQService) reconnect() *amqp091.Connection {
+	delay := reconnectInitialDelay
+
+	for {
+		s.setState(StateConnecting)
+
+		conn, err := amqp091.Dial(s.uri)
+		if err == nil {
+			if s.isClosed() {
+				conn.Close()
+				return nil
+			}
+
+			s.setConnection(conn)
+			log.Println("RabbitMQ connection re-established!")
+			return conn
+		}
+
+		s.setState(StateDisconnected)
+		log.Printf("Failed to reconnect to RabbitMQ, retrying in %s: %v", delay, err)
+
+		select {
+		case <-s.done:
+			return nil
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+}
+
+func (s *rabbitMQService) setState(state ConnectionState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == StateClosed {
+		return
+	}
+
+	s.state = state
+}
+
+func (s *rabbitMQService) isClosed() bool {
+	return s.State() == StateClosed
+}
+
+func (s *rabbitMQService) setConnection(conn *amqp091.Connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conn = conn
+	s.state = StateConnected
+
+	select {
+	case <-s.ready:
+	default:
+		close(s.ready)
+	}
+}
+
+func (s *rabbitMQService) markDisconnected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conn = nil
+	s.state = StateDisconnected
+	s.ready = make(chan struct{})
+}
+
+// waitForConnection memblokir sampai koneksi tersedia kembali, service
+// ditutup, atau ctx dibatalkan.
+func (s *rabbitMQService) waitForConnection(ctx context.Context) {
+	s.mu.RLock()
+	ready := s.ready
+	s.mu.RUnlock()
+
+	select {
+	case <-ready:
+	case <-s.done:
+	case <-ctx.Done():
+	}
+}
+
+// Close menghentikan reconnect, menutup channel publisher dan koneksi ke
+// broker. Channel delivery dari Consume ikut ditutup.
+func (s *rabbitMQService) Close() error {
+	s.mu.Lock()
+	if s.state == StateClosed {
+		s.mu.Unlock()
+		return nil
+	}
+
+	conn := s.conn
+	s.conn = nil
+	s.state = StateClosed
+	close(s.done)
+	s.mu.Unlock()
+
+drain:
+	for {
+		select {
+		case ch := <-s.publishers:
+			ch.Close()
+		default:
+			break drain
+		}
+	}
+
+	if conn != nil && !conn.IsClosed() {
+		if err := conn.Close(); err != nil {
+			return fmt.Errorf("failed to close RabbitMQ connection: %w", err)
+		}
+	}
+
+	log.Println("RabbitMQ connection closed")
+	return nil
+}
+
+func (s *rabbitMQService) connection() (*amqp091.Connection, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.conn == nil || s.conn.IsClosed() {
 		return nil, fmt.Errorf("RabbitMQ connection is not established")
 	}
 
-	ch, err := s.conn.Channel()
+	return s.conn, nil
+}
+
+func (s *rabbitMQService) openChannel() (*amqp091.Channel, error) {
+	conn, err := s.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
 	if err != nil {
 		return nil, fmt.Errorf("failed to open a channel: %w", err)
 	}
@@ -51,62 +249,184 @@ func (s *messagingService) GetChannel() (*amqp091.Channel, error) {
 	return ch, nil
 }
 
-func (s *messagingService) PublishEvent(exchangeName, routingKey string, body []byte) error {
-	if s.conn == nil {
-		return fmt.Errorf("RabbitMQ connection is not established")
+// PublishEvent mengirim message melalui channel dari pool dan baru kembali
+// setelah broker mengonfirmasi (publisher confirms) message tersebut.
+// contentType kosong dianggap application/json.
+func (s *rabbitMQService) PublishEvent(ctx context.Context, exchangeName, routingKey, partitionKey, messageID, contentType string, body []byte) error {
+	if contentType == "" {
+		contentType = "application/json"
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	ch, err := s.conn.Channel()
+	ch, err := s.acquirePublisherChannel()
 	if err != nil {
-		return fmt.Errorf("failed to open a channel: %w", err)
+		return err
 	}
-	defer ch.Close()
+	defer s.releasePublisherChannel(ch)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := withPublishTimeout(ctx)
 	defer cancel()
 
-	return ch.PublishWithContext(ctx,
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx,
 		exchangeName,
 		routingKey,
 		false,
 		false,
 		amqp091.Publishing{
-			ContentType: "application/json",
+			ContentType: contentType,
+			MessageId:   messageID,
 			Body:        body,
 		})
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for publisher confirm: %w", err)
+	}
+
+	if !acked {
+		return fmt.Errorf("message %s was nacked by the broker", messageID)
+	}
+
+	return nil
 }
 
-func (s *messagingService) Consume(queueName string, routingKey string) (<-chan amqp091.Delivery, error) {
-	if s.conn == nil {
-		return nil, fmt.Errorf("RabbitMQ connection is not established")
+// withPublishTimeout memakai deadline dari ctx bila ada, dan batas waktu
+// default jika tidak.
+func withPublishTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, publishTimeout)
+}
+
+// acquirePublisherChannel mengambil channel idle dari pool atau membuka
+// channel baru dalam confirm mode jika pool sedang kosong.
+func (s *rabbitMQService) acquirePublisherChannel() (*amqp091.Channel, error) {
+	for {
+		select {
+		case ch := <-s.publishers:
+			if ch.IsClosed() {
+				continue
+			}
+			return ch, nil
+		default:
+		}
+
+		ch, err := s.openChannel()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+		}
+
+		return ch, nil
+	}
+}
+
+func (s *rabbitMQService) releasePublisherChannel(ch *amqp091.Channel) {
+	if ch.IsClosed() {
+		return
 	}
 
-	ch, err := s.conn.Channel()
+	select {
+	case s.publishers <- ch:
+	default:
+		// Pool is full, drop the extra channel
+		ch.Close()
+	}
+}
+
+// Consume mengembalikan channel delivery yang tetap hidup melewati reconnect:
+// setiap kali channel AMQP tertutup, queue dan binding dideklarasikan ulang
+// lalu consumer didaftarkan kembali. Channel ditutup saat ctx dibatalkan atau
+// service ditutup.
+func (s *rabbitMQService) Consume(ctx context.Context, queueName string, routingKey string) (<-chan Message, error) {
+	return s.ConsumeExchange(ctx, os.Getenv("RABBITMQ_EXCHANGE_NAME"), queueName, routingKey)
+}
+
+// ConsumeExchange sama dengan Consume, tetapi queue di-bind ke exchange lain
+// dengan satu atau lebih routing key. Retry queue dan DLQ tetap memakai
+// exchange milik order-service.
+func (s *rabbitMQService) ConsumeExchange(ctx context.Context, exchangeName, queueName string, routingKeys ...string) (<-chan Message, error) {
+	deliveries, err := s.subscribe(exchangeName, queueName, routingKeys)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open a channel: %w", err)
+		return nil, err
 	}
 
-	q, err := ch.QueueDeclare(queueName, true, false, false, false, nil)
+	out := make(chan Message)
+
+	go func() {
+		defer close(out)
+
+		for {
+			for d := range deliveries {
+				select {
+				case out <- newRabbitMQMessage(d):
+				case <-ctx.Done():
+					// Unacked deliveries are requeued by the broker once the channel closes
+					return
+				}
+			}
+
+			if s.isClosed() || ctx.Err() != nil {
+				return
+			}
+
+			log.Printf("Consumer for queue %s stopped, waiting to resubscribe...", queueName)
+
+			for {
+				s.waitForConnection(ctx)
+				if s.isClosed() || ctx.Err() != nil {
+					return
+				}
+
+				deliveries, err = s.subscribe(exchangeName, queueName, routingKeys)
+				if err == nil {
+					log.Printf("Consumer for queue %s resubscribed", queueName)
+					break
+				}
+
+				log.Printf("Failed to resubscribe consumer for queue %s: %v", queueName, err)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(reconnectInitialDelay):
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (s *rabbitMQService) subscribe(exchangeName, queueName string, routingKeys []string) (<-chan amqp091.Delivery, error) {
+	ch, err := s.openChannel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to declare a queue: %w", err)
+		return nil, err
 	}
 
-	err = ch.QueueBind(
-		q.Name,
-		routingKey,
-		os.Getenv("RABBITMQ_EXCHANGE_NAME"),
-		false,
-		nil,
+	if err := declareTopology(ch, exchangeName, queueName, routingKeys); err != nil {
+		return nil, err
+	}
+
+	err = ch.Qos(
+		consumerPrefetchCount, // prefetchCount
+		0,                     // prefetchSize
+		false,                 // global
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to bind queue to exchange: %w", err)
+		return nil, fmt.Errorf("failed to set QoS: %w", err)
 	}
 
 	return ch.Consume(
-		q.Name,
+		queueName,
 		"",
 		false,
 		false,
@@ -116,46 +436,155 @@ func (s *messagingService) Consume(queueName string, routingKey string) (<-chan
 	)
 }
 
-func (s *messagingService) SetupFailedQueue() (<-chan amqp091.Delivery, error) {
-	if s.conn == nil {
-		return nil, fmt.Errorf("RabbitMQ connection is not established")
+// declareTopology mendeklarasikan queue utama beserta retry queue dan DLQ-nya:
+//
+//	exchange --routingKey--> queue --nack--> <exchange>.dlx --> <queue>.dlq
+//	<exchange>.retry --<queue>.retry.N--> <queue>.retry.N --TTL--> queue
+func declareTopology(ch *amqp091.Channel, exchangeName, queueName string, routingKeys []string) error {
+	for _, name := range []string{exchangeName, retryExchange(), deadLetterExchange()} {
+		err := ch.ExchangeDeclare(
+			name,     // name
+			"direct", // kind
+			true,     // durable
+			false,    // auto-delete
+			false,    // internal
+			false,    // no-wait
+			nil,      // arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare exchange %s: %w", name, err)
+		}
 	}
 
-	ch, err := s.conn.Channel()
+	_, err := ch.QueueDeclare(queueName, true, false, false, false, amqp091.Table{
+		"x-dead-letter-exchange":    deadLetterExchange(),
+		"x-dead-letter-routing-key": queueName,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open a channel: %w", err)
+		return fmt.Errorf("failed to declare a queue: %w", err)
 	}
 
-	q, err := ch.QueueDeclare(
-		"order-service.order.failed", // queue name
-		true,                         // durable
-		false,                        // auto-delete
-		false,                        // exclusive
-		false,                        // no-wait
-		nil,                          // arguments
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to declare queue: %w", err)
+	for _, routingKey := range routingKeys {
+		if err := ch.QueueBind(queueName, routingKey, exchangeName, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue to exchange: %w", err)
+		}
 	}
 
-	err = ch.QueueBind(
-		q.Name,
-		"order.failed",
-		os.Getenv("RABBITMQ_EXCHANGE_NAME"),
-		false,
-		nil,
-	)
+	dlqName := queueName + ".dlq"
+	if _, err := ch.QueueDeclare(dlqName, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+
+	if err := ch.QueueBind(dlqName, queueName, deadLetterExchange(), false, nil); err != nil {
+		return fmt.Errorf("failed to bind dead-letter queue: %w", err)
+	}
+
+	for i, delay := range RetryDelays {
+		retryQueue := retryQueueName(queueName, i+1)
+
+		// Expired messages go back to the main queue through the default exchange
+		_, err := ch.QueueDeclare(retryQueue, true, false, false, false, amqp091.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue: %w", err)
+		}
+
+		if err := ch.QueueBind(retryQueue, retryQueue, retryExchange(), false, nil); err != nil {
+			return fmt.Errorf("failed to bind retry queue: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Retry menjadwalkan ulang message ke retry queue untuk attempt tersebut.
+// Pemanggil tetap harus Ack message aslinya setelah Retry berhasil.
+func (s *rabbitMQService) Retry(ctx context.Context, queueName string, m Message, attempt int) error {
+	if attempt < 1 || attempt > len(RetryDelays) {
+		return fmt.Errorf("retry attempt %d out of range", attempt)
+	}
+
+	ch, err := s.acquirePublisherChannel()
 	if err != nil {
-		return nil, fmt.Errorf("failed to bind queue: %w", err)
+		return err
 	}
+	defer s.releasePublisherChannel(ch)
 
-	return ch.Consume(
-		q.Name,
-		"",
-		false,
-		false,
+	ctx, cancel := withPublishTimeout(ctx)
+	defer cancel()
+
+	headers := amqp091.Table{}
+	for k, v := range m.Headers {
+		headers[k] = v
+	}
+	headers[RetryCountHeader] = int32(attempt)
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx,
+		retryExchange(),
+		retryQueueName(queueName, attempt),
 		false,
 		false,
-		nil,
-	)
+		amqp091.Publishing{
+			ContentType: m.ContentType,
+			MessageId:   m.ID,
+			Headers:     headers,
+			Body:        m.Body,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to publish retry message: %w", err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for publisher confirm: %w", err)
+	}
+
+	if !acked {
+		return fmt.Errorf("retry message %s was nacked by the broker", m.ID)
+	}
+
+	return nil
+}
+
+// rabbitMQAcknowledger meneruskan Ack dan Nack ke delivery AMQP aslinya.
+type rabbitMQAcknowledger struct {
+	delivery amqp091.Delivery
+}
+
+func (a rabbitMQAcknowledger) Ack() error {
+	return a.delivery.Ack(false)
+}
+
+func (a rabbitMQAcknowledger) Nack(requeue bool) error {
+	return a.delivery.Nack(false, requeue)
+}
+
+func newRabbitMQMessage(d amqp091.Delivery) Message {
+	return Message{
+		ID:           d.MessageId,
+		RoutingKey:   d.RoutingKey,
+		ContentType:  d.ContentType,
+		Headers:      d.Headers,
+		Body:         d.Body,
+		Acknowledger: rabbitMQAcknowledger{delivery: d},
+	}
+}
+
+func retryExchange() string {
+	return os.Getenv("RABBITMQ_EXCHANGE_NAME") + ".retry"
+}
+
+func deadLetterExchange() string {
+	return os.Getenv("RABBITMQ_EXCHANGE_NAME") + ".dlx"
+}
+
+func retryQueueName(queueName string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queueName, attempt)
+}
+
+func (s *rabbitMQService) SetupFailedQueue(ctx context.Context) (<-chan Message, error) {
+	return s.Consume(ctx, "order-service.order.failed", "order.failed")
 }
@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"order-service/helpers"
+
+	"github.com/labstack/echo/v4"
+)
+
+const anonymousActor = "api"
+
+// Actor menyimpan siapa yang melakukan request ke context. Actor hanya diambil
+// dari token admin yang valid (nama admin di ADMIN_TOKENS), bukan dari header
+// yang bisa diisi bebas oleh client. Request lain dicatat sebagai "api".
+func Actor(credentials AdminCredentials) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			actor, ok := credentials.Authenticate(c.Request())
+			if !ok {
+				actor = anonymousActor
+			}
+
+			req := c.Request()
+			c.SetRequest(req.WithContext(helpers.WithActor(req.Context(), actor)))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"order-service/helpers"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActor(t *testing.T) {
+	credentials := AdminCredentials{"ops": "secret-1"}
+
+	var actor string
+	handler := Actor(credentials)(func(c echo.Context) error {
+		actor = helpers.ActorFromContext(c.Request().Context())
+		return nil
+	})
+
+	serve := func(headers map[string]string) string {
+		req := httptest.NewRequest(http.MethodPut, "/orders/10", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		handler(echo.New().NewContext(req, httptest.NewRecorder()))
+		return actor
+	}
+
+	assert.Equal(t, "ops", serve(map[string]string{echo.HeaderAuthorization: "Bearer secret-1"}))
+	assert.Equal(t, "api", serve(nil))
+
+	// Expect a client-chosen actor to be ignored
+	assert.Equal(t, "api", serve(map[string]string{"X-Actor": "ops"}))
+	assert.Equal(t, "api", serve(map[string]string{echo.HeaderAuthorization: "Bearer wrong", "X-Actor": "ops"}))
+}
@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"order-service/dto/response"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const adminAuthScheme = "Bearer "
+
+// AdminCredentials memetakan nama admin ke token miliknya.
+type AdminCredentials map[string]string
+
+// ParseAdminCredentials membaca ADMIN_TOKENS dengan format "nama:token,...".
+// Nilai kosong menghasilkan credential kosong sehingga semua request admin ditolak.
+func ParseAdminCredentials(value string) (AdminCredentials, error) {
+	credentials := AdminCredentials{}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, token, ok := strings.Cut(pair, ":")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("invalid admin token entry %q", pair)
+		}
+
+		credentials[name] = token
+	}
+
+	return credentials, nil
+}
+
+// Authenticate mengembalikan nama admin pemilik token di header
+// Authorization: Bearer <token>.
+func (c AdminCredentials) Authenticate(req *http.Request) (string, bool) {
+	header := req.Header.Get(echo.HeaderAuthorization)
+	if !strings.HasPrefix(header, adminAuthScheme) {
+		return "", false
+	}
+
+	token := []byte(strings.TrimPrefix(header, adminAuthScheme))
+
+	// Compare against every token so the response time does not leak which one matched
+	var admin string
+	for name, expected := range c {
+		if subtle.ConstantTimeCompare(token, []byte(expected)) == 1 {
+			admin = name
+		}
+	}
+
+	return admin, admin != ""
+}
+
+// AdminAuth menolak request yang tidak membawa token admin yang valid.
+func AdminAuth(credentials AdminCredentials) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if _, ok := credentials.Authenticate(c.Request()); !ok {
+				return c.JSON(http.StatusUnauthorized, response.BaseResponse{
+					Status:  false,
+					Message: http.StatusText(http.StatusUnauthorized),
+					Error:   "a valid admin token is required",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAdminCredentials(t *testing.T) {
+	t.Run("should parse name and token pairs", func(t *testing.T) {
+		credentials, err := ParseAdminCredentials("ops:secret-1, support:secret-2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, AdminCredentials{"ops": "secret-1", "support": "secret-2"}, credentials)
+	})
+
+	t.Run("should reject entries without a token", func(t *testing.T) {
+		_, err := ParseAdminCredentials("ops")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestAdminAuth(t *testing.T) {
+	credentials := AdminCredentials{"ops": "secret-1"}
+	handler := AdminAuth(credentials)(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	serve := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/failed-orders", nil)
+		if authorization != "" {
+			req.Header.Set(echo.HeaderAuthorization, authorization)
+		}
+		rec := httptest.NewRecorder()
+
+		handler(echo.New().NewContext(req, rec))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("Bearer secret-1"))
+	assert.Equal(t, http.StatusUnauthorized, serve(""))
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer wrong"))
+	assert.Equal(t, http.StatusUnauthorized, serve("secret-1"))
+
+	// Expect every request to be rejected when no admin token is configured
+	handler = AdminAuth(AdminCredentials{})(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	assert.Equal(t, http.StatusUnauthorized, serve("Bearer "))
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"order-service/helpers"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// CorrelationID meneruskan header X-Correlation-ID ke context request dan
+// response. Request tanpa header mendapat ID baru.
+func CorrelationID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			correlationID := c.Request().Header.Get(CorrelationIDHeader)
+			if correlationID == "" {
+				correlationID = uuid.NewString()
+			}
+
+			c.Response().Header().Set(CorrelationIDHeader, correlationID)
+
+			req := c.Request()
+			c.SetRequest(req.WithContext(helpers.WithCorrelationID(req.Context(), correlationID)))
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/failed_order_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=repositories/failed_order_repository.go -destination=mocks/mock_failed_order_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	entities "order-service/entities"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFailedOrderRepository is a mock of FailedOrderRepository interface.
+type MockFailedOrderRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFailedOrderRepositoryMockRecorder
+}
+
+// MockFailedOrderRepositoryMockRecorder is the mock recorder for MockFailedOrderRepository.
+type MockFailedOrderRepositoryMockRecorder struct {
+	mock *MockFailedOrderRepository
+}
+
+// NewMockFailedOrderRepository creates a new mock instance.
+func NewMockFailedOrderRepository(ctrl *gomock.Controller) *MockFailedOrderRepository {
+	mock := &MockFailedOrderRepository{ctrl: ctrl}
+	mock.recorder = &MockFailedOrderRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFailedOrderRepository) EXPECT() *MockFailedOrderRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method.
+func (m *MockFailedOrderRepository) Count(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockFailedOrderRepositoryMockRecorder) Count(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockFailedOrderRepository)(nil).Count), ctx)
+}
+
+// Create mocks base method.
+func (m *MockFailedOrderRepository) Create(ctx context.Context, failedOrder entities.FailedOrder) (entities.FailedOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, failedOrder)
+	ret0, _ := ret[0].(entities.FailedOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockFailedOrderRepositoryMockRecorder) Create(ctx, failedOrder any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockFailedOrderRepository)(nil).Create), ctx, failedOrder)
+}
+
+// FindAll mocks base method.
+func (m *MockFailedOrderRepository) FindAll(ctx context.Context, limit, offset int) ([]entities.FailedOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", ctx, limit, offset)
+	ret0, _ := ret[0].([]entities.FailedOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockFailedOrderRepositoryMockRecorder) FindAll(ctx, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockFailedOrderRepository)(nil).FindAll), ctx, limit, offset)
+}
+
+// FindByID mocks base method.
+func (m *MockFailedOrderRepository) FindByID(ctx context.Context, id uint) (entities.FailedOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(entities.FailedOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockFailedOrderRepositoryMockRecorder) FindByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockFailedOrderRepository)(nil).FindByID), ctx, id)
+}
+
+// MarkReplayed mocks base method.
+func (m *MockFailedOrderRepository) MarkReplayed(ctx context.Context, id uint) (entities.FailedOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkReplayed", ctx, id)
+	ret0, _ := ret[0].(entities.FailedOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkReplayed indicates an expected call of MarkReplayed.
+func (mr *MockFailedOrderRepositoryMockRecorder) MarkReplayed(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkReplayed", reflect.TypeOf((*MockFailedOrderRepository)(nil).MarkReplayed), ctx, id)
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: services/order_service_impl.go
+// Source: clients/http_client.go
 //
 // Generated by this command:
 //
-//	mockgen -source=services/order_service_impl.go -destination=mocks/mock_http_client.go -package=mocks HTTPClient
+//	mockgen -source=clients/http_client.go -destination=mocks/mock_http_client.go -package=mocks
 //
 
 // Package mocks is a generated GoMock package.
@@ -39,17 +39,17 @@ func (m *MockHTTPClient) EXPECT() *MockHTTPClientMockRecorder {
 	return m.recorder
 }
 
-// Get mocks base method.
-func (m *MockHTTPClient) Get(url string) (*http.Response, error) {
+// Do mocks base method.
+func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", url)
+	ret := m.ctrl.Call(m, "Do", req)
 	ret0, _ := ret[0].(*http.Response)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// Get indicates an expected call of Get.
-func (mr *MockHTTPClientMockRecorder) Get(url any) *gomock.Call {
+// Do indicates an expected call of Do.
+func (mr *MockHTTPClientMockRecorder) Do(req any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockHTTPClient)(nil).Get), url)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockHTTPClient)(nil).Do), req)
 }
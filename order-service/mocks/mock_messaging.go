@@ -0,0 +1,212 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: messaging/messaging.go
+//
+// Generated by this command:
+//
+//	mockgen -source=messaging/messaging.go -destination=mocks/mock_messaging.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	messaging "order-service/messaging"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessagingService is a mock of MessagingService interface.
+type MockMessagingService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessagingServiceMockRecorder
+}
+
+// MockMessagingServiceMockRecorder is the mock recorder for MockMessagingService.
+type MockMessagingServiceMockRecorder struct {
+	mock *MockMessagingService
+}
+
+// NewMockMessagingService creates a new mock instance.
+func NewMockMessagingService(ctrl *gomock.Controller) *MockMessagingService {
+	mock := &MockMessagingService{ctrl: ctrl}
+	mock.recorder = &MockMessagingServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessagingService) EXPECT() *MockMessagingServiceMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockMessagingService) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockMessagingServiceMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockMessagingService)(nil).Close))
+}
+
+// Connect mocks base method.
+func (m *MockMessagingService) Connect() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Connect")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Connect indicates an expected call of Connect.
+func (mr *MockMessagingServiceMockRecorder) Connect() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockMessagingService)(nil).Connect))
+}
+
+// Consume mocks base method.
+func (m *MockMessagingService) Consume(ctx context.Context, queueName, routingKey string) (<-chan messaging.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, queueName, routingKey)
+	ret0, _ := ret[0].(<-chan messaging.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockMessagingServiceMockRecorder) Consume(ctx, queueName, routingKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockMessagingService)(nil).Consume), ctx, queueName, routingKey)
+}
+
+// ConsumeExchange mocks base method.
+func (m *MockMessagingService) ConsumeExchange(ctx context.Context, exchangeName, queueName string, routingKeys ...string) (<-chan messaging.Message, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, exchangeName, queueName}
+	for _, a := range routingKeys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ConsumeExchange", varargs...)
+	ret0, _ := ret[0].(<-chan messaging.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeExchange indicates an expected call of ConsumeExchange.
+func (mr *MockMessagingServiceMockRecorder) ConsumeExchange(ctx, exchangeName, queueName any, routingKeys ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, exchangeName, queueName}, routingKeys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeExchange", reflect.TypeOf((*MockMessagingService)(nil).ConsumeExchange), varargs...)
+}
+
+// PublishEvent mocks base method.
+func (m *MockMessagingService) PublishEvent(ctx context.Context, exchangeName, routingKey, partitionKey, messageID, contentType string, body []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishEvent", ctx, exchangeName, routingKey, partitionKey, messageID, contentType, body)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishEvent indicates an expected call of PublishEvent.
+func (mr *MockMessagingServiceMockRecorder) PublishEvent(ctx, exchangeName, routingKey, partitionKey, messageID, contentType, body any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishEvent", reflect.TypeOf((*MockMessagingService)(nil).PublishEvent), ctx, exchangeName, routingKey, partitionKey, messageID, contentType, body)
+}
+
+// Retry mocks base method.
+func (m_2 *MockMessagingService) Retry(ctx context.Context, queueName string, m messaging.Message, attempt int) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Retry", ctx, queueName, m, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Retry indicates an expected call of Retry.
+func (mr *MockMessagingServiceMockRecorder) Retry(ctx, queueName, m, attempt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Retry", reflect.TypeOf((*MockMessagingService)(nil).Retry), ctx, queueName, m, attempt)
+}
+
+// SetupFailedQueue mocks base method.
+func (m *MockMessagingService) SetupFailedQueue(ctx context.Context) (<-chan messaging.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetupFailedQueue", ctx)
+	ret0, _ := ret[0].(<-chan messaging.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetupFailedQueue indicates an expected call of SetupFailedQueue.
+func (mr *MockMessagingServiceMockRecorder) SetupFailedQueue(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetupFailedQueue", reflect.TypeOf((*MockMessagingService)(nil).SetupFailedQueue), ctx)
+}
+
+// State mocks base method.
+func (m *MockMessagingService) State() messaging.ConnectionState {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(messaging.ConnectionState)
+	return ret0
+}
+
+// State indicates an expected call of State.
+func (mr *MockMessagingServiceMockRecorder) State() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockMessagingService)(nil).State))
+}
+
+// MockAcknowledger is a mock of Acknowledger interface.
+type MockAcknowledger struct {
+	ctrl     *gomock.Controller
+	recorder *MockAcknowledgerMockRecorder
+}
+
+// MockAcknowledgerMockRecorder is the mock recorder for MockAcknowledger.
+type MockAcknowledgerMockRecorder struct {
+	mock *MockAcknowledger
+}
+
+// NewMockAcknowledger creates a new mock instance.
+func NewMockAcknowledger(ctrl *gomock.Controller) *MockAcknowledger {
+	mock := &MockAcknowledger{ctrl: ctrl}
+	mock.recorder = &MockAcknowledgerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAcknowledger) EXPECT() *MockAcknowledgerMockRecorder {
+	return m.recorder
+}
+
+// Ack mocks base method.
+func (m *MockAcknowledger) Ack() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ack")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ack indicates an expected call of Ack.
+func (mr *MockAcknowledgerMockRecorder) Ack() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ack", reflect.TypeOf((*MockAcknowledger)(nil).Ack))
+}
+
+// Nack mocks base method.
+func (m *MockAcknowledger) Nack(requeue bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Nack", requeue)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Nack indicates an expected call of Nack.
+func (mr *MockAcknowledgerMockRecorder) Nack(requeue any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Nack", reflect.TypeOf((*MockAcknowledger)(nil).Nack), requeue)
+}
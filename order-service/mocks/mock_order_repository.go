@@ -10,8 +10,10 @@
 package mocks
 
 import (
+	context "context"
 	entities "order-service/entities"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -39,91 +41,164 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Count mocks base method.
+func (m *MockOrderRepository) Count(ctx context.Context, filter entities.OrderFilter) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count", ctx, filter)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockOrderRepositoryMockRecorder) Count(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockOrderRepository)(nil).Count), ctx, filter)
+}
+
 // Create mocks base method.
-func (m *MockOrderRepository) Create(order entities.Order) (entities.Order, error) {
+func (m *MockOrderRepository) Create(ctx context.Context, order entities.Order) (entities.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", order)
+	ret := m.ctrl.Call(m, "Create", ctx, order)
 	ret0, _ := ret[0].(entities.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockOrderRepositoryMockRecorder) Create(order any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) Create(ctx, order any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderRepository)(nil).Create), order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderRepository)(nil).Create), ctx, order)
 }
 
 // Delete mocks base method.
-func (m *MockOrderRepository) Delete(id uint) error {
+func (m *MockOrderRepository) Delete(ctx context.Context, id uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockOrderRepositoryMockRecorder) Delete(id any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrderRepository)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrderRepository)(nil).Delete), ctx, id)
 }
 
 // FindAll mocks base method.
-func (m *MockOrderRepository) FindAll() ([]entities.Order, error) {
+func (m *MockOrderRepository) FindAll(ctx context.Context, filter entities.OrderFilter) ([]entities.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAll")
+	ret := m.ctrl.Call(m, "FindAll", ctx, filter)
 	ret0, _ := ret[0].([]entities.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindAll indicates an expected call of FindAll.
-func (mr *MockOrderRepositoryMockRecorder) FindAll() *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) FindAll(ctx, filter any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockOrderRepository)(nil).FindAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockOrderRepository)(nil).FindAll), ctx, filter)
 }
 
 // FindByID mocks base method.
-func (m *MockOrderRepository) FindByID(id uint) (entities.Order, error) {
+func (m *MockOrderRepository) FindByID(ctx context.Context, id uint) (entities.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByID", id)
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
 	ret0, _ := ret[0].(entities.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindByID indicates an expected call of FindByID.
-func (mr *MockOrderRepositoryMockRecorder) FindByID(id any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) FindByID(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockOrderRepository)(nil).FindByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockOrderRepository)(nil).FindByID), ctx, id)
+}
+
+// FindByIDForUpdate mocks base method.
+func (m *MockOrderRepository) FindByIDForUpdate(ctx context.Context, id uint) (entities.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDForUpdate", ctx, id)
+	ret0, _ := ret[0].(entities.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDForUpdate indicates an expected call of FindByIDForUpdate.
+func (mr *MockOrderRepositoryMockRecorder) FindByIDForUpdate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDForUpdate", reflect.TypeOf((*MockOrderRepository)(nil).FindByIDForUpdate), ctx, id)
 }
 
 // FindByProductID mocks base method.
-func (m *MockOrderRepository) FindByProductID(productID uint) ([]entities.Order, error) {
+func (m *MockOrderRepository) FindByProductID(ctx context.Context, productID uint) ([]entities.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByProductID", productID)
+	ret := m.ctrl.Call(m, "FindByProductID", ctx, productID)
 	ret0, _ := ret[0].([]entities.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindByProductID indicates an expected call of FindByProductID.
-func (mr *MockOrderRepositoryMockRecorder) FindByProductID(productID any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) FindByProductID(ctx, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByProductID", reflect.TypeOf((*MockOrderRepository)(nil).FindByProductID), ctx, productID)
+}
+
+// FindStale mocks base method.
+func (m *MockOrderRepository) FindStale(ctx context.Context, status entities.OrderStatus, updatedBefore time.Time, limit int) ([]entities.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStale", ctx, status, updatedBefore, limit)
+	ret0, _ := ret[0].([]entities.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStale indicates an expected call of FindStale.
+func (mr *MockOrderRepositoryMockRecorder) FindStale(ctx, status, updatedBefore, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByProductID", reflect.TypeOf((*MockOrderRepository)(nil).FindByProductID), productID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStale", reflect.TypeOf((*MockOrderRepository)(nil).FindStale), ctx, status, updatedBefore, limit)
+}
+
+// Restore mocks base method.
+func (m *MockOrderRepository) Restore(ctx context.Context, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockOrderRepositoryMockRecorder) Restore(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockOrderRepository)(nil).Restore), ctx, id)
 }
 
 // Update mocks base method.
-func (m *MockOrderRepository) Update(order entities.Order) (entities.Order, error) {
+func (m *MockOrderRepository) Update(ctx context.Context, order entities.Order) (entities.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", order)
+	ret := m.ctrl.Call(m, "Update", ctx, order)
 	ret0, _ := ret[0].(entities.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockOrderRepositoryMockRecorder) Update(order any) *gomock.Call {
+func (mr *MockOrderRepositoryMockRecorder) Update(ctx, order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockOrderRepository)(nil).Update), ctx, order)
+}
+
+// UpdateItems mocks base method.
+func (m *MockOrderRepository) UpdateItems(ctx context.Context, items []entities.OrderItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateItems", ctx, items)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateItems indicates an expected call of UpdateItems.
+func (mr *MockOrderRepositoryMockRecorder) UpdateItems(ctx, items any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockOrderRepository)(nil).Update), order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItems", reflect.TypeOf((*MockOrderRepository)(nil).UpdateItems), ctx, items)
 }
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/order_status_history_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=repositories/order_status_history_repository.go -destination=mocks/mock_order_status_history_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	entities "order-service/entities"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrderStatusHistoryRepository is a mock of OrderStatusHistoryRepository interface.
+type MockOrderStatusHistoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderStatusHistoryRepositoryMockRecorder
+}
+
+// MockOrderStatusHistoryRepositoryMockRecorder is the mock recorder for MockOrderStatusHistoryRepository.
+type MockOrderStatusHistoryRepositoryMockRecorder struct {
+	mock *MockOrderStatusHistoryRepository
+}
+
+// NewMockOrderStatusHistoryRepository creates a new mock instance.
+func NewMockOrderStatusHistoryRepository(ctrl *gomock.Controller) *MockOrderStatusHistoryRepository {
+	mock := &MockOrderStatusHistoryRepository{ctrl: ctrl}
+	mock.recorder = &MockOrderStatusHistoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderStatusHistoryRepository) EXPECT() *MockOrderStatusHistoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOrderStatusHistoryRepository) Create(ctx context.Context, history entities.OrderStatusHistory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, history)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOrderStatusHistoryRepositoryMockRecorder) Create(ctx, history any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderStatusHistoryRepository)(nil).Create), ctx, history)
+}
+
+// FindByOrderID mocks base method.
+func (m *MockOrderStatusHistoryRepository) FindByOrderID(ctx context.Context, orderID uint) ([]entities.OrderStatusHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByOrderID", ctx, orderID)
+	ret0, _ := ret[0].([]entities.OrderStatusHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByOrderID indicates an expected call of FindByOrderID.
+func (mr *MockOrderStatusHistoryRepositoryMockRecorder) FindByOrderID(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByOrderID", reflect.TypeOf((*MockOrderStatusHistoryRepository)(nil).FindByOrderID), ctx, orderID)
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: repositories/processed_message_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=repositories/processed_message_repository.go -destination=mocks/mock_processed_message_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProcessedMessageRepository is a mock of ProcessedMessageRepository interface.
+type MockProcessedMessageRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockProcessedMessageRepositoryMockRecorder
+}
+
+// MockProcessedMessageRepositoryMockRecorder is the mock recorder for MockProcessedMessageRepository.
+type MockProcessedMessageRepositoryMockRecorder struct {
+	mock *MockProcessedMessageRepository
+}
+
+// NewMockProcessedMessageRepository creates a new mock instance.
+func NewMockProcessedMessageRepository(ctrl *gomock.Controller) *MockProcessedMessageRepository {
+	mock := &MockProcessedMessageRepository{ctrl: ctrl}
+	mock.recorder = &MockProcessedMessageRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProcessedMessageRepository) EXPECT() *MockProcessedMessageRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockProcessedMessageRepository) Create(ctx context.Context, messageID, consumer string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, messageID, consumer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockProcessedMessageRepositoryMockRecorder) Create(ctx, messageID, consumer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProcessedMessageRepository)(nil).Create), ctx, messageID, consumer)
+}
+
+// Exists mocks base method.
+func (m *MockProcessedMessageRepository) Exists(ctx context.Context, messageID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exists", ctx, messageID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exists indicates an expected call of Exists.
+func (mr *MockProcessedMessageRepositoryMockRecorder) Exists(ctx, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockProcessedMessageRepository)(nil).Exists), ctx, messageID)
+}
@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: clients/product_catalog.go
+//
+// Generated by this command:
+//
+//	mockgen -source=clients/product_catalog.go -destination=mocks/mock_product_catalog.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	clients "order-service/clients"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProductCatalog is a mock of ProductCatalog interface.
+type MockProductCatalog struct {
+	ctrl     *gomock.Controller
+	recorder *MockProductCatalogMockRecorder
+}
+
+// MockProductCatalogMockRecorder is the mock recorder for MockProductCatalog.
+type MockProductCatalogMockRecorder struct {
+	mock *MockProductCatalog
+}
+
+// NewMockProductCatalog creates a new mock instance.
+func NewMockProductCatalog(ctrl *gomock.Controller) *MockProductCatalog {
+	mock := &MockProductCatalog{ctrl: ctrl}
+	mock.recorder = &MockProductCatalogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProductCatalog) EXPECT() *MockProductCatalogMockRecorder {
+	return m.recorder
+}
+
+// GetProduct mocks base method.
+func (m *MockProductCatalog) GetProduct(ctx context.Context, id uint) (clients.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProduct", ctx, id)
+	ret0, _ := ret[0].(clients.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProduct indicates an expected call of GetProduct.
+func (mr *MockProductCatalogMockRecorder) GetProduct(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockProductCatalog)(nil).GetProduct), ctx, id)
+}
+
+// GetProducts mocks base method.
+func (m *MockProductCatalog) GetProducts(ctx context.Context, ids []uint) (map[uint]clients.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProducts", ctx, ids)
+	ret0, _ := ret[0].(map[uint]clients.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProducts indicates an expected call of GetProducts.
+func (mr *MockProductCatalogMockRecorder) GetProducts(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProducts", reflect.TypeOf((*MockProductCatalog)(nil).GetProducts), ctx, ids)
+}
+
+// Remove mocks base method.
+func (m *MockProductCatalog) Remove(ctx context.Context, id uint, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", ctx, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockProductCatalogMockRecorder) Remove(ctx, id, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockProductCatalog)(nil).Remove), ctx, id, version)
+}
+
+// Save mocks base method.
+func (m *MockProductCatalog) Save(ctx context.Context, product clients.Product) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, product)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockProductCatalogMockRecorder) Save(ctx, product any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockProductCatalog)(nil).Save), ctx, product)
+}
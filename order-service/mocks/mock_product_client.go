@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: clients/product_client.go
+//
+// Generated by this command:
+//
+//	mockgen -source=clients/product_client.go -destination=mocks/mock_product_client.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	clients "order-service/clients"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProductClient is a mock of ProductClient interface.
+type MockProductClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockProductClientMockRecorder
+}
+
+// MockProductClientMockRecorder is the mock recorder for MockProductClient.
+type MockProductClientMockRecorder struct {
+	mock *MockProductClient
+}
+
+// NewMockProductClient creates a new mock instance.
+func NewMockProductClient(ctrl *gomock.Controller) *MockProductClient {
+	mock := &MockProductClient{ctrl: ctrl}
+	mock.recorder = &MockProductClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProductClient) EXPECT() *MockProductClientMockRecorder {
+	return m.recorder
+}
+
+// GetProduct mocks base method.
+func (m *MockProductClient) GetProduct(ctx context.Context, id uint) (clients.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProduct", ctx, id)
+	ret0, _ := ret[0].(clients.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProduct indicates an expected call of GetProduct.
+func (mr *MockProductClientMockRecorder) GetProduct(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockProductClient)(nil).GetProduct), ctx, id)
+}
+
+// GetProducts mocks base method.
+func (m *MockProductClient) GetProducts(ctx context.Context, ids []uint) (map[uint]clients.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProducts", ctx, ids)
+	ret0, _ := ret[0].(map[uint]clients.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProducts indicates an expected call of GetProducts.
+func (mr *MockProductClientMockRecorder) GetProducts(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProducts", reflect.TypeOf((*MockProductClient)(nil).GetProducts), ctx, ids)
+}
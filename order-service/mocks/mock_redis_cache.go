@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
@@ -39,10 +40,24 @@ func (m *MockCacheService) EXPECT() *MockCacheServiceMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockCacheService) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockCacheServiceMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockCacheService)(nil).Close))
+}
+
 // Del mocks base method.
-func (m *MockCacheService) Del(keys ...string) error {
+func (m *MockCacheService) Del(ctx context.Context, keys ...string) error {
 	m.ctrl.T.Helper()
-	varargs := []any{}
+	varargs := []any{ctx}
 	for _, a := range keys {
 		varargs = append(varargs, a)
 	}
@@ -52,36 +67,52 @@ func (m *MockCacheService) Del(keys ...string) error {
 }
 
 // Del indicates an expected call of Del.
-func (mr *MockCacheServiceMockRecorder) Del(keys ...any) *gomock.Call {
+func (mr *MockCacheServiceMockRecorder) Del(ctx any, keys ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockCacheService)(nil).Del), keys...)
+	varargs := append([]any{ctx}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockCacheService)(nil).Del), varargs...)
 }
 
 // Get mocks base method.
-func (m *MockCacheService) Get(key string) (string, error) {
+func (m *MockCacheService) Get(ctx context.Context, key string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", key)
+	ret := m.ctrl.Call(m, "Get", ctx, key)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockCacheServiceMockRecorder) Get(key any) *gomock.Call {
+func (mr *MockCacheServiceMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCacheService)(nil).Get), ctx, key)
+}
+
+// SetNX mocks base method.
+func (m *MockCacheService) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNX", ctx, key, value, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNX indicates an expected call of SetNX.
+func (mr *MockCacheServiceMockRecorder) SetNX(ctx, key, value, ttl any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCacheService)(nil).Get), key)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNX", reflect.TypeOf((*MockCacheService)(nil).SetNX), ctx, key, value, ttl)
 }
 
 // SetWithTTL mocks base method.
-func (m *MockCacheService) SetWithTTL(key, value string, ttl time.Duration) error {
+func (m *MockCacheService) SetWithTTL(ctx context.Context, key, value string, ttl time.Duration) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetWithTTL", key, value, ttl)
+	ret := m.ctrl.Call(m, "SetWithTTL", ctx, key, value, ttl)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetWithTTL indicates an expected call of SetWithTTL.
-func (mr *MockCacheServiceMockRecorder) SetWithTTL(key, value, ttl any) *gomock.Call {
+func (mr *MockCacheServiceMockRecorder) SetWithTTL(ctx, key, value, ttl any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithTTL", reflect.TypeOf((*MockCacheService)(nil).SetWithTTL), key, value, ttl)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithTTL", reflect.TypeOf((*MockCacheService)(nil).SetWithTTL), ctx, key, value, ttl)
 }
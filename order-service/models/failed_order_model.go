@@ -0,0 +1,65 @@
+package models
+
+import (
+	"order-service/entities"
+	"time"
+)
+
+type FailedOrder struct {
+	ID          uint       `gorm:"primaryKey"`
+	OrderID     uint       `gorm:"index" json:"order_id"`
+	Reason      string     `json:"reason"`
+	Payload     string     `gorm:"type:text" json:"payload"`
+	ReplayCount int        `json:"replay_count"`
+	ReplayedAt  *time.Time `json:"replayed_at"`
+	FailedAt    time.Time  `json:"failed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type FailedOrders []FailedOrder
+
+func (f FailedOrder) FromEntity(failedOrder entities.FailedOrder) FailedOrder {
+	var replayedAt *time.Time
+	if !failedOrder.ReplayedAt.IsZero() {
+		replayedAt = &failedOrder.ReplayedAt
+	}
+
+	return FailedOrder{
+		ID:          failedOrder.ID,
+		OrderID:     failedOrder.OrderID,
+		Reason:      failedOrder.Reason,
+		Payload:     failedOrder.Payload,
+		ReplayCount: failedOrder.ReplayCount,
+		ReplayedAt:  replayedAt,
+		FailedAt:    failedOrder.FailedAt,
+		CreatedAt:   failedOrder.CreatedAt,
+	}
+}
+
+func (f *FailedOrder) ToEntity() entities.FailedOrder {
+	failedOrder := entities.FailedOrder{
+		ID:          f.ID,
+		OrderID:     f.OrderID,
+		Reason:      f.Reason,
+		Payload:     f.Payload,
+		ReplayCount: f.ReplayCount,
+		FailedAt:    f.FailedAt,
+		CreatedAt:   f.CreatedAt,
+	}
+
+	if f.ReplayedAt != nil {
+		failedOrder.ReplayedAt = *f.ReplayedAt
+	}
+
+	return failedOrder
+}
+
+func (fs *FailedOrders) ToEntities() []entities.FailedOrder {
+	data := []entities.FailedOrder{}
+
+	for _, v := range *fs {
+		data = append(data, v.ToEntity())
+	}
+
+	return data
+}
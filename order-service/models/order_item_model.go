@@ -0,0 +1,60 @@
+package models
+
+import "order-service/entities"
+
+type OrderItem struct {
+	ID        uint    `gorm:"primaryKey"`
+	OrderID   uint    `gorm:"index" json:"order_id"`
+	ProductID uint    `gorm:"index" json:"product_id"`
+	Qty       int     `json:"qty"`
+	UnitPrice float64 `json:"unit_price"`
+	Subtotal  float64 `json:"subtotal"`
+}
+
+type OrderItems []OrderItem
+
+func (i OrderItem) FromEntity(item entities.OrderItem) OrderItem {
+	return OrderItem{
+		ID:        item.ID,
+		OrderID:   item.OrderID,
+		ProductID: item.ProductID,
+		Qty:       item.Qty,
+		UnitPrice: item.UnitPrice,
+		Subtotal:  item.Subtotal,
+	}
+}
+
+func (i *OrderItem) ToEntity() entities.OrderItem {
+	return entities.OrderItem{
+		ID:        i.ID,
+		OrderID:   i.OrderID,
+		ProductID: i.ProductID,
+		Qty:       i.Qty,
+		UnitPrice: i.UnitPrice,
+		Subtotal:  i.Subtotal,
+	}
+}
+
+func (is OrderItems) FromEntities(items []entities.OrderItem) OrderItems {
+	if items == nil {
+		return nil
+	}
+
+	data := OrderItems{}
+
+	for _, v := range items {
+		data = append(data, OrderItem{}.FromEntity(v))
+	}
+
+	return data
+}
+
+func (is *OrderItems) ToEntities() []entities.OrderItem {
+	data := []entities.OrderItem{}
+
+	for _, v := range *is {
+		data = append(data, v.ToEntity())
+	}
+
+	return data
+}
@@ -3,42 +3,54 @@ package models
 import (
 	"order-service/entities"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Order struct {
-	ID         uint      `gorm:"primaryKey"`
-	ProductID  uint      `json:"product_id"`
-	Qty        int       `json:"qty"`
-	TotalPrice float64   `json:"total_price"`
-	Status     string    `json:"status"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         uint           `gorm:"primaryKey"`
+	Items      OrderItems     `gorm:"foreignKey:OrderID" json:"items"`
+	TotalPrice float64        `json:"total_price"`
+	Status     string         `json:"status"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at"`
 }
 
 type Orders []Order
 
 func (o Order) FromEntity(order entities.Order) Order {
+	var deletedAt gorm.DeletedAt
+	if order.DeletedAt != nil {
+		deletedAt = gorm.DeletedAt{Time: *order.DeletedAt, Valid: true}
+	}
+
 	return Order{
 		ID:         order.ID,
-		ProductID:  order.ProductID,
+		Items:      OrderItems{}.FromEntities(order.Items),
 		TotalPrice: order.TotalPrice,
-		Qty:        order.Qty,
-		Status:     order.Status,
+		Status:     string(order.Status),
 		CreatedAt:  order.CreatedAt,
 		UpdatedAt:  order.UpdatedAt,
+		DeletedAt:  deletedAt,
 	}
 }
 
 func (o *Order) ToEntity() entities.Order {
-	return entities.Order{
+	order := entities.Order{
 		ID:         o.ID,
-		ProductID:  o.ProductID,
-		Qty:        o.Qty,
+		Items:      o.Items.ToEntities(),
 		TotalPrice: o.TotalPrice,
-		Status:     o.Status,
+		Status:     entities.OrderStatus(o.Status),
 		CreatedAt:  o.CreatedAt,
 		UpdatedAt:  o.UpdatedAt,
 	}
+
+	if o.DeletedAt.Valid {
+		order.DeletedAt = &o.DeletedAt.Time
+	}
+
+	return order
 }
 
 func (os Orders) FromEntities(orders []entities.Order) Orders {
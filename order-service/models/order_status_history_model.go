@@ -0,0 +1,56 @@
+package models
+
+import (
+	"order-service/entities"
+	"time"
+)
+
+type OrderStatusHistory struct {
+	ID        uint      `gorm:"primaryKey"`
+	OrderID   uint      `gorm:"index" json:"order_id"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrderStatusHistories []OrderStatusHistory
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+func (h OrderStatusHistory) FromEntity(history entities.OrderStatusHistory) OrderStatusHistory {
+	return OrderStatusHistory{
+		ID:        history.ID,
+		OrderID:   history.OrderID,
+		OldStatus: string(history.OldStatus),
+		NewStatus: string(history.NewStatus),
+		Reason:    history.Reason,
+		Actor:     history.Actor,
+		CreatedAt: history.CreatedAt,
+	}
+}
+
+func (h *OrderStatusHistory) ToEntity() entities.OrderStatusHistory {
+	return entities.OrderStatusHistory{
+		ID:        h.ID,
+		OrderID:   h.OrderID,
+		OldStatus: entities.OrderStatus(h.OldStatus),
+		NewStatus: entities.OrderStatus(h.NewStatus),
+		Reason:    h.Reason,
+		Actor:     h.Actor,
+		CreatedAt: h.CreatedAt,
+	}
+}
+
+func (hs *OrderStatusHistories) ToEntities() []entities.OrderStatusHistory {
+	data := []entities.OrderStatusHistory{}
+
+	for _, v := range *hs {
+		data = append(data, v.ToEntity())
+	}
+
+	return data
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"order-service/entities"
+	"time"
+)
+
+type OutboxEvent struct {
+	ID            string     `gorm:"primaryKey;type:uuid"`
+	Exchange      string     `json:"exchange"`
+	RoutingKey    string     `gorm:"index:idx_outbox_events_pending_key,priority:2" json:"routing_key"`
+	PartitionKey  string     `gorm:"index:idx_outbox_events_pending_key,priority:1,where:status = 'pending'" json:"partition_key"`
+	ContentType   string     `gorm:"not null;default:'application/json'" json:"content_type"`
+	Payload       []byte     `json:"payload"`
+	Status        string     `gorm:"index:idx_outbox_events_pending,priority:1" json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt time.Time  `gorm:"index:idx_outbox_events_pending,priority:2" json:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `gorm:"index:idx_outbox_events_pending_key,priority:3" json:"created_at"`
+}
+
+type OutboxEvents []OutboxEvent
+
+func (o OutboxEvent) FromEntity(event entities.OutboxEvent) OutboxEvent {
+	var sentAt *time.Time
+	if !event.SentAt.IsZero() {
+		sentAt = &event.SentAt
+	}
+
+	return OutboxEvent{
+		ID:            event.ID,
+		Exchange:      event.Exchange,
+		RoutingKey:    event.RoutingKey,
+		PartitionKey:  event.PartitionKey,
+		ContentType:   event.ContentType,
+		Payload:       event.Payload,
+		Status:        event.Status,
+		Attempts:      event.Attempts,
+		LastError:     event.LastError,
+		NextAttemptAt: event.NextAttemptAt,
+		SentAt:        sentAt,
+		CreatedAt:     event.CreatedAt,
+	}
+}
+
+func (o *OutboxEvent) ToEntity() entities.OutboxEvent {
+	event := entities.OutboxEvent{
+		ID:            o.ID,
+		Exchange:      o.Exchange,
+		RoutingKey:    o.RoutingKey,
+		PartitionKey:  o.PartitionKey,
+		ContentType:   o.ContentType,
+		Payload:       o.Payload,
+		Status:        o.Status,
+		Attempts:      o.Attempts,
+		LastError:     o.LastError,
+		NextAttemptAt: o.NextAttemptAt,
+		CreatedAt:     o.CreatedAt,
+	}
+
+	if o.SentAt != nil {
+		event.SentAt = *o.SentAt
+	}
+
+	return event
+}
+
+func (os *OutboxEvents) ToEntities() []entities.OutboxEvent {
+	data := []entities.OutboxEvent{}
+
+	for _, v := range *os {
+		data = append(data, v.ToEntity())
+	}
+
+	return data
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type ProcessedMessage struct {
+	MessageID   string    `gorm:"primaryKey"`
+	Consumer    string    `json:"consumer"`
+	ProcessedAt time.Time `gorm:"autoCreateTime" json:"processed_at"`
+}
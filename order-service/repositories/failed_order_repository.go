@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"order-service/entities"
+)
+
+type FailedOrderRepository interface {
+	Create(ctx context.Context, failedOrder entities.FailedOrder) (entities.FailedOrder, error)
+	FindAll(ctx context.Context, limit, offset int) ([]entities.FailedOrder, error)
+	Count(ctx context.Context) (int64, error)
+	FindByID(ctx context.Context, id uint) (entities.FailedOrder, error)
+	MarkReplayed(ctx context.Context, id uint) (entities.FailedOrder, error)
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"order-service/entities"
+	"order-service/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type failedOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewFailedOrderRepository(db *gorm.DB) FailedOrderRepository {
+	return &failedOrderRepository{
+		db: db,
+	}
+}
+
+func (r *failedOrderRepository) Create(ctx context.Context, failedOrder entities.FailedOrder) (entities.FailedOrder, error) {
+	failedOrderModel := models.FailedOrder{}.FromEntity(failedOrder)
+
+	if err := r.db.WithContext(ctx).Create(&failedOrderModel).Error; err != nil {
+		return entities.FailedOrder{}, err
+	}
+
+	return failedOrderModel.ToEntity(), nil
+}
+
+func (r *failedOrderRepository) FindAll(ctx context.Context, limit, offset int) ([]entities.FailedOrder, error) {
+	var failedOrdersModel models.FailedOrders
+
+	if err := r.db.WithContext(ctx).Order("id DESC").Limit(limit).Offset(offset).Find(&failedOrdersModel).Error; err != nil {
+		return nil, err
+	}
+
+	return failedOrdersModel.ToEntities(), nil
+}
+
+func (r *failedOrderRepository) Count(ctx context.Context) (int64, error) {
+	var total int64
+
+	if err := r.db.WithContext(ctx).Model(&models.FailedOrder{}).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (r *failedOrderRepository) FindByID(ctx context.Context, id uint) (entities.FailedOrder, error) {
+	failedOrderModel := models.FailedOrder{}
+
+	if err := r.db.WithContext(ctx).First(&failedOrderModel, id).Error; err != nil {
+		return entities.FailedOrder{}, err
+	}
+
+	return failedOrderModel.ToEntity(), nil
+}
+
+func (r *failedOrderRepository) MarkReplayed(ctx context.Context, id uint) (entities.FailedOrder, error) {
+	err := r.db.WithContext(ctx).Model(&models.FailedOrder{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"replay_count": gorm.Expr("replay_count + 1"),
+			"replayed_at":  time.Now(),
+		}).Error
+	if err != nil {
+		return entities.FailedOrder{}, err
+	}
+
+	return r.FindByID(ctx, id)
+}
@@ -1,12 +1,21 @@
 package repositories
 
-import "order-service/entities"
+import (
+	"context"
+	"order-service/entities"
+	"time"
+)
 
 type OrderRepository interface {
-	Create(order entities.Order) (entities.Order, error)
-	FindAll() ([]entities.Order, error)
-	FindByID(id uint) (entities.Order, error)
-	FindByProductID(productID uint) ([]entities.Order, error)
-	Update(order entities.Order) (entities.Order, error)
-	Delete(id uint) error
+	Create(ctx context.Context, order entities.Order) (entities.Order, error)
+	FindAll(ctx context.Context, filter entities.OrderFilter) ([]entities.Order, error)
+	Count(ctx context.Context, filter entities.OrderFilter) (int64, error)
+	FindByID(ctx context.Context, id uint) (entities.Order, error)
+	FindByIDForUpdate(ctx context.Context, id uint) (entities.Order, error)
+	FindByProductID(ctx context.Context, productID uint) ([]entities.Order, error)
+	FindStale(ctx context.Context, status entities.OrderStatus, updatedBefore time.Time, limit int) ([]entities.Order, error)
+	Update(ctx context.Context, order entities.Order) (entities.Order, error)
+	UpdateItems(ctx context.Context, items []entities.OrderItem) error
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
 }
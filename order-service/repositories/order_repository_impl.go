@@ -1,10 +1,13 @@
 package repositories
 
 import (
+	"context"
 	"order-service/entities"
 	"order-service/models"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type orderRepository struct {
@@ -17,66 +20,195 @@ func NewOrderRepository(db *gorm.DB) OrderRepository {
 	}
 }
 
-func (r *orderRepository) Create(order entities.Order) (entities.Order, error) {
+func (r *orderRepository) Create(ctx context.Context, order entities.Order) (entities.Order, error) {
 	orderModel := models.Order{}.FromEntity(order)
 
-	if err := r.db.Create(&orderModel).Error; err != nil {
+	if err := r.db.WithContext(ctx).Create(&orderModel).Error; err != nil {
 		return entities.Order{}, err
 	}
 
 	return orderModel.ToEntity(), nil
 }
 
-func (r *orderRepository) FindAll() ([]entities.Order, error) {
+func (r *orderRepository) FindAll(ctx context.Context, filter entities.OrderFilter) ([]entities.Order, error) {
 	var ordersModel models.Orders
 
-	if err := r.db.Find(&ordersModel).Error; err != nil {
+	query := applyOrderFilter(r.db.WithContext(ctx).Model(&models.Order{}), filter)
+
+	direction := "ASC"
+	comparator := ">"
+	if filter.SortDesc {
+		direction = "DESC"
+		comparator = "<"
+	}
+
+	if filter.SortBy == entities.OrderSortByCreatedAt {
+		if filter.Cursor != nil {
+			query = query.Where("(created_at, id) "+comparator+" (?, ?)", filter.Cursor.CreatedAt, filter.Cursor.ID)
+		}
+		query = query.Order("created_at " + direction).Order("id " + direction)
+	} else {
+		if filter.Cursor != nil {
+			query = query.Where("id "+comparator+" ?", filter.Cursor.ID)
+		}
+		query = query.Order("id " + direction)
+	}
+
+	if filter.Cursor == nil && filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	if err := query.Preload("Items").Limit(filter.Limit).Find(&ordersModel).Error; err != nil {
 		return nil, err
 	}
 
 	return ordersModel.ToEntities(), nil
 }
 
-func (r *orderRepository) FindByID(id uint) (entities.Order, error) {
+func (r *orderRepository) Count(ctx context.Context, filter entities.OrderFilter) (int64, error) {
+	var total int64
+
+	if err := applyOrderFilter(r.db.WithContext(ctx).Model(&models.Order{}), filter).Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// orderIDsByProduct adalah subquery ID order yang memiliki item dengan produk tersebut.
+func orderIDsByProduct(db *gorm.DB, productID uint) *gorm.DB {
+	return db.Model(&models.OrderItem{}).Select("order_id").Where("product_id = ?", productID)
+}
+
+// UpdateItems menyimpan harga setiap item setelah produk divalidasi.
+func (r *orderRepository) UpdateItems(ctx context.Context, items []entities.OrderItem) error {
+	for _, item := range items {
+		err := r.db.WithContext(ctx).Model(&models.OrderItem{}).Where("id = ?", item.ID).Updates(map[string]interface{}{
+			"unit_price": item.UnitPrice,
+			"subtotal":   item.Subtotal,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyOrderFilter menambahkan kondisi filter tanpa pagination dan sorting.
+func applyOrderFilter(query *gorm.DB, filter entities.OrderFilter) *gorm.DB {
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.ProductID != 0 {
+		query = query.Where("id IN (?)", orderIDsByProduct(query.Session(&gorm.Session{NewDB: true}), filter.ProductID))
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at <= ?", filter.CreatedTo)
+	}
+
+	return query
+}
+
+func (r *orderRepository) FindByID(ctx context.Context, id uint) (entities.Order, error) {
+	orderModel := models.Order{}
+
+	if err := r.db.WithContext(ctx).Preload("Items").First(&orderModel, &id).Error; err != nil {
+		return entities.Order{}, err
+	}
+
+	return orderModel.ToEntity(), nil
+}
+
+// FindByIDForUpdate mengunci baris order sampai transaksi selesai, sehingga
+// perubahan status yang bersamaan tidak saling menimpa.
+func (r *orderRepository) FindByIDForUpdate(ctx context.Context, id uint) (entities.Order, error) {
 	orderModel := models.Order{}
 
-	if err := r.db.First(&orderModel, &id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&orderModel, &id).Error; err != nil {
 		return entities.Order{}, err
 	}
 
 	return orderModel.ToEntity(), nil
 }
 
-func (r *orderRepository) FindByProductID(productID uint) ([]entities.Order, error) {
+func (r *orderRepository) FindByProductID(ctx context.Context, productID uint) ([]entities.Order, error) {
 	var ordersModel models.Orders
 
-	if err := r.db.Where("product_id = ?", productID).Find(&ordersModel).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id IN (?)", orderIDsByProduct(r.db.WithContext(ctx), productID)).Preload("Items").Find(&ordersModel).Error; err != nil {
 		return nil, err
 	}
 
 	return ordersModel.ToEntities(), nil
 }
 
-func (r *orderRepository) Update(order entities.Order) (entities.Order, error) {
+// FindStale mengambil order dengan status tertentu yang tidak berubah sejak
+// updatedBefore. Baris yang sedang dikunci transaksi lain dilewati.
+func (r *orderRepository) FindStale(ctx context.Context, status entities.OrderStatus, updatedBefore time.Time, limit int) ([]entities.Order, error) {
+	var ordersModel models.Orders
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND updated_at < ?", status, updatedBefore).
+		Order("updated_at").
+		Limit(limit).
+		Preload("Items").
+		Find(&ordersModel).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ordersModel.ToEntities(), nil
+}
+
+func (r *orderRepository) Update(ctx context.Context, order entities.Order) (entities.Order, error) {
 	orderModel := models.Order{}.FromEntity(order)
 
-	if err := r.db.Updates(&orderModel).Error; err != nil {
+	if err := r.db.WithContext(ctx).Updates(&orderModel).Error; err != nil {
 		return entities.Order{}, err
 	}
 
-	if err := r.db.First(&orderModel, order.ID).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Items").First(&orderModel, order.ID).Error; err != nil {
 		return entities.Order{}, err
 	}
 
 	return orderModel.ToEntity(), nil
 }
 
-func (r *orderRepository) Delete(id uint) error {
+func (r *orderRepository) Delete(ctx context.Context, id uint) error {
 	orderModel := models.Order{}
 
-	if err := r.db.Delete(&orderModel, &id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Delete(&orderModel, &id).Error; err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Restore mengembalikan order yang sudah di-soft delete. gorm.ErrRecordNotFound
+// dikembalikan jika tidak ada order terhapus dengan ID tersebut.
+func (r *orderRepository) Restore(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Unscoped().
+		Model(&models.Order{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"context"
+	"order-service/entities"
+)
+
+type OrderStatusHistoryRepository interface {
+	Create(ctx context.Context, history entities.OrderStatusHistory) error
+	FindByOrderID(ctx context.Context, orderID uint) ([]entities.OrderStatusHistory, error)
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"order-service/entities"
+	"order-service/models"
+
+	"gorm.io/gorm"
+)
+
+type orderStatusHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderStatusHistoryRepository(db *gorm.DB) OrderStatusHistoryRepository {
+	return &orderStatusHistoryRepository{
+		db: db,
+	}
+}
+
+func (r *orderStatusHistoryRepository) Create(ctx context.Context, history entities.OrderStatusHistory) error {
+	historyModel := models.OrderStatusHistory{}.FromEntity(history)
+
+	return r.db.WithContext(ctx).Create(&historyModel).Error
+}
+
+func (r *orderStatusHistoryRepository) FindByOrderID(ctx context.Context, orderID uint) ([]entities.OrderStatusHistory, error) {
+	var historiesModel models.OrderStatusHistories
+
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("id").Find(&historiesModel).Error; err != nil {
+		return nil, err
+	}
+
+	return historiesModel.ToEntities(), nil
+}
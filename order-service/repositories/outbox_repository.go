@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"order-service/entities"
+	"time"
+)
+
+type OutboxRepository interface {
+	Create(ctx context.Context, event entities.OutboxEvent) error
+	FindPending(ctx context.Context, limit int) ([]entities.OutboxEvent, error)
+	MarkSent(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt time.Time, lastError string) error
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"order-service/entities"
+	"order-service/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{
+		db: db,
+	}
+}
+
+func (r *outboxRepository) Create(ctx context.Context, event entities.OutboxEvent) error {
+	eventModel := models.OutboxEvent{}.FromEntity(event)
+
+	return r.db.WithContext(ctx).Create(&eventModel).Error
+}
+
+// FindPending mengambil event yang siap dikirim dan mengunci barisnya,
+// sehingga beberapa relay bisa berjalan bersamaan tanpa mengirim event yang sama.
+// Event yang masih punya event lebih lama berstatus pending dengan routing key
+// dan partition key yang sama tidak diambil, agar urutan per key terjaga
+// selama backoff.
+func (r *outboxRepository) FindPending(ctx context.Context, limit int) ([]entities.OutboxEvent, error) {
+	var eventsModel models.OutboxEvents
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", "pending", time.Now()).
+		Where(`COALESCE(partition_key, '') = '' OR NOT EXISTS (
+			SELECT 1 FROM outbox_events AS older
+			WHERE older.partition_key = outbox_events.partition_key
+			AND older.routing_key = outbox_events.routing_key
+			AND older.status = ?
+			AND older.created_at < outbox_events.created_at
+		)`, "pending").
+		Order("created_at").
+		Limit(limit).
+		Find(&eventsModel).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return eventsModel.ToEntities(), nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     "sent",
+			"sent_at":    time.Now(),
+			"last_error": "",
+		}).Error
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
@@ -0,0 +1,8 @@
+package repositories
+
+import "context"
+
+type ProcessedMessageRepository interface {
+	Exists(ctx context.Context, messageID string) (bool, error)
+	Create(ctx context.Context, messageID, consumer string) error
+}
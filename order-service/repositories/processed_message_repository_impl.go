@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"order-service/models"
+
+	"gorm.io/gorm"
+)
+
+type processedMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewProcessedMessageRepository(db *gorm.DB) ProcessedMessageRepository {
+	return &processedMessageRepository{
+		db: db,
+	}
+}
+
+func (r *processedMessageRepository) Exists(ctx context.Context, messageID string) (bool, error) {
+	var count int64
+
+	if err := r.db.WithContext(ctx).Model(&models.ProcessedMessage{}).Where("message_id = ?", messageID).Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *processedMessageRepository) Create(ctx context.Context, messageID, consumer string) error {
+	return r.db.WithContext(ctx).Create(&models.ProcessedMessage{
+		MessageID: messageID,
+		Consumer:  consumer,
+	}).Error
+}
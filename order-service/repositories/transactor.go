@@ -0,0 +1,16 @@
+package repositories
+
+import "context"
+
+// Repositories berisi repository yang berbagi satu transaksi database.
+type Repositories struct {
+	Orders            OrderRepository
+	Outbox            OutboxRepository
+	ProcessedMessages ProcessedMessageRepository
+	FailedOrders      FailedOrderRepository
+	StatusHistory     OrderStatusHistoryRepository
+}
+
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(repos Repositories) error) error
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{
+		db: db,
+	}
+}
+
+// WithinTransaction menjalankan fn dalam satu transaksi GORM. Transaksi
+// di-rollback jika fn mengembalikan error.
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(repos Repositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(Repositories{
+			Orders:            NewOrderRepository(tx),
+			Outbox:            NewOutboxRepository(tx),
+			ProcessedMessages: NewProcessedMessageRepository(tx),
+			FailedOrders:      NewFailedOrderRepository(tx),
+			StatusHistory:     NewOrderStatusHistoryRepository(tx),
+		})
+	})
+}
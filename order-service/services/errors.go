@@ -0,0 +1,18 @@
+package services
+
+import "errors"
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+	ErrOrderNotReplayable       = errors.New("only failed orders can be replayed")
+	ErrInvalidOrderStatus       = errors.New("invalid order status")
+	ErrInvalidStatusTransition  = errors.New("order status cannot be changed")
+	ErrOrderNotCancellable      = errors.New("order cannot be cancelled")
+	ErrOrderNotDeleted          = errors.New("order is not deleted")
+	ErrOrderNotDeletable        = errors.New("order is still being processed and cannot be deleted")
+
+	// errOrderNotPending menandai order yang statusnya sudah diubah (misalnya
+	// dibatalkan) sejak dibaca consumer, sehingga message cukup di-ack.
+	errOrderNotPending = errors.New("order is no longer pending")
+)
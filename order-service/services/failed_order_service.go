@@ -0,0 +1,11 @@
+package services
+
+import (
+	"context"
+	"order-service/entities"
+)
+
+type FailedOrderService interface {
+	FindAll(ctx context.Context, limit, offset int) (entities.FailedOrderPage, error)
+	Replay(ctx context.Context, id uint) (entities.FailedOrder, error)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"order-service/database"
+	"order-service/entities"
+	"order-service/events"
+	"order-service/repositories"
+	"os"
+)
+
+type failedOrderService struct {
+	failedOrderRepo repositories.FailedOrderRepository
+	transactor      repositories.Transactor
+	cache           database.CacheService
+	exchange        string
+}
+
+func NewFailedOrderService(
+	failedOrderRepo repositories.FailedOrderRepository,
+	transactor repositories.Transactor,
+	cache database.CacheService,
+) FailedOrderService {
+	return &failedOrderService{
+		failedOrderRepo: failedOrderRepo,
+		transactor:      transactor,
+		cache:           cache,
+		exchange:        os.Getenv("RABBITMQ_EXCHANGE_NAME"),
+	}
+}
+
+func (s *failedOrderService) FindAll(ctx context.Context, limit, offset int) (entities.FailedOrderPage, error) {
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+
+	total, err := s.failedOrderRepo.Count(ctx)
+	if err != nil {
+		return entities.FailedOrderPage{}, err
+	}
+
+	// Ambil satu baris ekstra untuk mengetahui apakah masih ada halaman berikutnya.
+	failedOrders, err := s.failedOrderRepo.FindAll(ctx, limit+1, offset)
+	if err != nil {
+		return entities.FailedOrderPage{}, err
+	}
+
+	page := entities.FailedOrderPage{
+		FailedOrders: failedOrders,
+		Total:        total,
+		Limit:        limit,
+		Offset:       offset,
+	}
+
+	if len(failedOrders) > limit {
+		page.FailedOrders = failedOrders[:limit]
+		page.HasMore = true
+	}
+
+	return page, nil
+}
+
+// Replay mengembalikan order ke status pending lalu mengirim ulang
+// order.created.request lewat outbox dengan message ID baru.
+func (s *failedOrderService) Replay(ctx context.Context, id uint) (entities.FailedOrder, error) {
+	failedOrder, err := s.failedOrderRepo.FindByID(ctx, id)
+	if err != nil {
+		return entities.FailedOrder{}, err
+	}
+
+	var (
+		order         entities.Order
+		replayedOrder entities.FailedOrder
+	)
+	err = s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		var err error
+		order, err = repos.Orders.FindByIDForUpdate(ctx, failedOrder.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if !order.Status.CanTransitionTo(entities.OrderStatusPending) {
+			return ErrOrderNotReplayable
+		}
+
+		if _, err := repos.Orders.Update(ctx, entities.Order{ID: order.ID, Status: entities.OrderStatusPending}); err != nil {
+			return err
+		}
+
+		if err := recordStatusChange(ctx, repos, order.ID, order.Status, entities.OrderStatusPending, fmt.Sprintf("Replayed failed order %d", failedOrder.ID)); err != nil {
+			return err
+		}
+
+		event, err := newOutboxEvent(ctx, s.exchange, events.TypeOrderCreatedRequest, events.OrderCreatedRequest{
+			OrderID: order.ID,
+			Items:   orderItemsPayload(order.Items),
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := repos.Outbox.Create(ctx, event); err != nil {
+			return err
+		}
+
+		replayedOrder, err = repos.FailedOrders.MarkReplayed(ctx, failedOrder.ID)
+		return err
+	})
+	if err != nil {
+		return entities.FailedOrder{}, err
+	}
+
+	invalidateOrderCache(ctx, s.cache, order)
+
+	return replayedOrder, nil
+}
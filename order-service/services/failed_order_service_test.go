@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"order-service/entities"
+	"order-service/events"
+	"order-service/mocks"
+	"order-service/repositories"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFailedOrderService_Replay(t *testing.T) {
+	failedOrder := entities.FailedOrder{ID: 1, OrderID: 10, Reason: "Insufficient stock"}
+
+	t.Run("should reset order to pending and republish the order request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFailedOrderRepo := mocks.NewMockFailedOrderRepository(ctrl)
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		s := NewFailedOrderService(mockFailedOrderRepo, mockTransactor, mockCache)
+
+		replayedOrder := failedOrder
+		replayedOrder.ReplayCount = 1
+
+		mockFailedOrderRepo.EXPECT().FindByID(gomock.Any(), uint(1)).Return(failedOrder, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, FailedOrders: mockFailedOrderRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "failed"}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: "pending"}).Return(entities.Order{ID: 10, Status: "pending"}, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusFailed, NewStatus: entities.OrderStatusPending, Reason: "Replayed failed order 1", Actor: "system"}).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.created.request", event.RoutingKey)
+			assert.JSONEq(t, `{"orderID":10,"items":[{"productID":123,"qty":2}]}`, eventData(t, events.TypeOrderCreatedRequest, event.Payload))
+			return nil
+		})
+		mockFailedOrderRepo.EXPECT().MarkReplayed(gomock.Any(), uint(1)).Return(replayedOrder, nil)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		result, err := s.Replay(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, replayedOrder, result)
+	})
+
+	t.Run("should reject replay when order is no longer failed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFailedOrderRepo := mocks.NewMockFailedOrderRepository(ctrl)
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewFailedOrderService(mockFailedOrderRepo, mockTransactor, mockCache)
+
+		mockFailedOrderRepo.EXPECT().FindByID(gomock.Any(), uint(1)).Return(failedOrder, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, FailedOrders: mockFailedOrderRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: "completed"}, nil)
+
+		result, err := s.Replay(context.Background(), 1)
+
+		assert.Equal(t, entities.FailedOrder{}, result)
+		assert.ErrorIs(t, err, ErrOrderNotReplayable)
+	})
+}
+
+func TestFailedOrderService_FindAll(t *testing.T) {
+	t.Run("should page failed orders with the default limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFailedOrderRepo := mocks.NewMockFailedOrderRepository(ctrl)
+		s := NewFailedOrderService(mockFailedOrderRepo, mocks.NewMockTransactor(ctrl), mocks.NewMockCacheService(ctrl))
+
+		failedOrders := make([]entities.FailedOrder, defaultPageLimit+1)
+		for i := range failedOrders {
+			failedOrders[i] = entities.FailedOrder{ID: uint(100 - i)}
+		}
+
+		mockFailedOrderRepo.EXPECT().Count(gomock.Any()).Return(int64(50), nil)
+
+		// Expect one extra row to be fetched to detect the next page
+		mockFailedOrderRepo.EXPECT().FindAll(gomock.Any(), defaultPageLimit+1, 20).Return(failedOrders, nil)
+
+		page, err := s.FindAll(context.Background(), 0, 20)
+
+		assert.NoError(t, err)
+		assert.Len(t, page.FailedOrders, defaultPageLimit)
+		assert.Equal(t, int64(50), page.Total)
+		assert.Equal(t, defaultPageLimit, page.Limit)
+		assert.Equal(t, 20, page.Offset)
+		assert.True(t, page.HasMore)
+	})
+
+	t.Run("should report the last page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFailedOrderRepo := mocks.NewMockFailedOrderRepository(ctrl)
+		s := NewFailedOrderService(mockFailedOrderRepo, mocks.NewMockTransactor(ctrl), mocks.NewMockCacheService(ctrl))
+
+		mockFailedOrderRepo.EXPECT().Count(gomock.Any()).Return(int64(1), nil)
+		mockFailedOrderRepo.EXPECT().FindAll(gomock.Any(), 6, 0).Return([]entities.FailedOrder{{ID: 1}}, nil)
+
+		page, err := s.FindAll(context.Background(), 5, 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, page.FailedOrders, 1)
+		assert.False(t, page.HasMore)
+	})
+}
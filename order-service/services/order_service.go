@@ -1,14 +1,21 @@
 package services
 
-import "order-service/entities"
+import (
+	"context"
+	"order-service/entities"
+)
 
 type OrderService interface {
-	Create(order entities.Order) (entities.Order, error)
-	FindAll() ([]entities.Order, error)
-	FindByID(id uint) (entities.Order, error)
-	FindByProductID(productID uint) ([]entities.Order, error)
-	Update(order entities.Order) (entities.Order, error)
-	Delete(id uint) error
-	StartOrderConsumer()
-	StartOrderFailedConsumer()
-}
\ No newline at end of file
+	Create(ctx context.Context, order entities.Order, idempotencyKey string) (entities.Order, error)
+	FindAll(ctx context.Context, filter entities.OrderFilter) (entities.OrderPage, error)
+	FindByID(ctx context.Context, id uint) (entities.Order, error)
+	FindByProductID(ctx context.Context, productID uint) ([]entities.Order, error)
+	Update(ctx context.Context, order entities.Order, reason string) (entities.Order, error)
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) (entities.Order, error)
+	Cancel(ctx context.Context, id uint) (entities.Order, error)
+	FindHistory(ctx context.Context, orderID uint) ([]entities.OrderStatusHistory, error)
+	StartOrderConsumer(ctx context.Context)
+	StartOrderFailedConsumer(ctx context.Context)
+	StartStockReplyConsumer(ctx context.Context)
+}
@@ -1,235 +1,751 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
+	"order-service/clients"
 	"order-service/database"
 	"order-service/entities"
+	"order-service/events"
+	"order-service/helpers"
 	"order-service/messaging"
 	"order-service/repositories"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/rabbitmq/amqp091-go"
+	"gorm.io/gorm"
 )
 
-const cacheTTL = 5 * time.Minute
+const (
+	cacheTTL       = 5 * time.Minute
+	idempotencyTTL = 24 * time.Hour
+	messageTimeout = 30 * time.Second
+	maxOrderItems  = 50
 
-type Product struct {
-	ID    uint    `json:"id"`
-	Price float64 `json:"price"`
-	Qty   int     `json:"qty"`
-}
+	// idempotencyReservationTTL cukup pendek agar key yang tertinggal karena
+	// proses crash atau Redis gagal menyimpan hasil tidak memblokir retry.
+	idempotencyReservationTTL = 30 * time.Second
 
-type ProductResponse struct {
-	Data Product
-}
+	defaultPageLimit = 20
+
+	orderRequestQueue  = "order-service.order.requests"
+	failedOrderQueue   = "order-service.order.failed"
+	stockReservedQueue = "order-service.stock.reserved"
+	stockRejectedQueue = "order-service.stock.rejected"
+)
 
-type HTTPClient interface {
-	Get(url string) (resp *http.Response, err error)
+type idempotencyRecord struct {
+	Fingerprint string         `json:"fingerprint"`
+	Order       entities.Order `json:"order"`
 }
 
 type orderService struct {
-	orderRepo  repositories.OrderRepository
-	httpClient HTTPClient
-	messaging  messaging.MessagingService
-	cache      database.CacheService
-	exchange   string
+	orderRepo            repositories.OrderRepository
+	processedMessageRepo repositories.ProcessedMessageRepository
+	statusHistoryRepo    repositories.OrderStatusHistoryRepository
+	transactor           repositories.Transactor
+	productClient        clients.ProductClient
+	messaging            messaging.MessagingService
+	cache                database.CacheService
+	exchange             string
+	// stockSaga memesan stok lewat stock.reserve sebelum order completed.
+	// Jika false, stok dicek dari data produk lalu dikurangi lewat order.created.
+	stockSaga bool
 }
 
 func NewOrderService(
 	orderRepo repositories.OrderRepository,
-	httpClient HTTPClient,
+	processedMessageRepo repositories.ProcessedMessageRepository,
+	statusHistoryRepo repositories.OrderStatusHistoryRepository,
+	transactor repositories.Transactor,
+	productClient clients.ProductClient,
 	messaging messaging.MessagingService,
 	cache database.CacheService,
 ) OrderService {
 	return &orderService{
-		orderRepo:  orderRepo,
-		httpClient: httpClient,
-		messaging:  messaging,
-		cache:      cache,
-		exchange:   os.Getenv("RABBITMQ_EXCHANGE_NAME"),
+		orderRepo:            orderRepo,
+		processedMessageRepo: processedMessageRepo,
+		statusHistoryRepo:    statusHistoryRepo,
+		transactor:           transactor,
+		productClient:        productClient,
+		messaging:            messaging,
+		cache:                cache,
+		exchange:             os.Getenv("RABBITMQ_EXCHANGE_NAME"),
+		stockSaga:            StockSagaEnabled(),
 	}
 }
 
-func (s *orderService) Create(order entities.Order) (entities.Order, error) {
-	if order.ProductID == 0 || order.Qty <= 0 {
+// StockSagaEnabled membaca STOCK_RESERVATION_SAGA. Default false selama
+// product-service belum menangani stock.reserve dan stock.release.
+func StockSagaEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("STOCK_RESERVATION_SAGA"))
+	return enabled
+}
+
+func (s *orderService) Create(ctx context.Context, order entities.Order, idempotencyKey string) (entities.Order, error) {
+	if len(order.Items) == 0 || len(order.Items) > maxOrderItems {
 		return entities.Order{}, fmt.Errorf("invalid order data")
 	}
 
-	go func(order entities.Order) {
-		eventPayload := map[string]interface{}{
-			"productID": order.ProductID,
-			"qty":       order.Qty,
+	for _, item := range order.Items {
+		if item.ProductID == 0 || item.Qty <= 0 {
+			return entities.Order{}, fmt.Errorf("invalid order data")
 		}
+	}
+
+	if idempotencyKey == "" {
+		return s.createOrder(ctx, order)
+	}
+
+	cacheKey := "orders:idempotency:" + idempotencyKey
+	fingerprint := orderFingerprint(order)
+
+	// Reserve the key first so concurrent retries cannot both create an order
+	reservation, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+	acquired, err := s.cache.SetNX(ctx, cacheKey, string(reservation), idempotencyReservationTTL)
+	if err != nil {
+		return entities.Order{}, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	if !acquired {
+		return s.replayOrder(ctx, cacheKey, fingerprint)
+	}
+
+	createdOrder, err := s.createOrder(ctx, order)
+	if err != nil {
+		s.cache.Del(ctx, cacheKey)
+		return entities.Order{}, err
+	}
+
+	// Only a stored result is kept for the full TTL; otherwise the short
+	// reservation expires and a retry creates the order again
+	record, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Order: createdOrder})
+	if err := s.cache.SetWithTTL(ctx, cacheKey, string(record), idempotencyTTL); err != nil {
+		log.Printf("Failed to store idempotency record for order ID %d: %v", createdOrder.ID, err)
+	}
 
-		jsonData, err := json.Marshal(eventPayload)
+	return createdOrder, nil
+}
+
+// replayOrder mengembalikan order yang dibuat oleh request sebelumnya
+// dengan Idempotency-Key yang sama.
+func (s *orderService) replayOrder(ctx context.Context, cacheKey, fingerprint string) (entities.Order, error) {
+	val, err := s.cache.Get(ctx, cacheKey)
+	if err != nil {
+		return entities.Order{}, fmt.Errorf("failed to load idempotency record: %w", err)
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(val), &record); err != nil {
+		return entities.Order{}, fmt.Errorf("failed to decode idempotency record: %w", err)
+	}
+
+	if record.Fingerprint != fingerprint {
+		return entities.Order{}, ErrIdempotencyKeyReused
+	}
+
+	if record.Order.ID == 0 {
+		return entities.Order{}, ErrIdempotencyKeyInProgress
+	}
+
+	return record.Order, nil
+}
+
+func orderFingerprint(order entities.Order) string {
+	var sb strings.Builder
+	for _, item := range order.Items {
+		fmt.Fprintf(&sb, "%d:%d;", item.ProductID, item.Qty)
+	}
+
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *orderService) createOrder(ctx context.Context, order entities.Order) (entities.Order, error) {
+	order.Status = entities.OrderStatusPending
+
+	var createdOrder entities.Order
+	err := s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		var err error
+		createdOrder, err = repos.Orders.Create(ctx, order)
 		if err != nil {
-			log.Printf("Failed to marshal order payload: %v", err)
-			return
+			return err
 		}
 
-		if err := s.messaging.PublishEvent(
-			s.exchange,
-			"order.created.request",
-			jsonData,
-		); err != nil {
-			log.Printf("Failed to publish RabbitMQ event: %v", err)
+		if err := recordStatusChange(ctx, repos, createdOrder.ID, "", createdOrder.Status, "Order created"); err != nil {
+			return err
 		}
-	}(order)
 
-	return order, nil
+		event, err := newOutboxEvent(ctx, s.exchange, events.TypeOrderCreatedRequest, events.OrderCreatedRequest{
+			OrderID: createdOrder.ID,
+			Items:   orderItemsPayload(createdOrder.Items),
+		})
+		if err != nil {
+			return err
+		}
+
+		return repos.Outbox.Create(ctx, event)
+	})
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	invalidateOrderCache(ctx, s.cache, createdOrder)
+
+	return createdOrder, nil
+}
+
+// orderItemsPayload menyiapkan daftar item untuk payload event.
+func orderItemsPayload(items []entities.OrderItem) []events.OrderItem {
+	payload := make([]events.OrderItem, 0, len(items))
+	for _, item := range items {
+		payload = append(payload, events.OrderItem{
+			ProductID: item.ProductID,
+			Qty:       item.Qty,
+		})
+	}
+
+	return payload
 }
 
-func (s *orderService) StartOrderConsumer() {
-	ch, err := s.messaging.GetChannel()
+// invalidateOrderCache menghapus cache order dan cache daftar order untuk
+// setiap produk di dalamnya.
+func invalidateOrderCache(ctx context.Context, cache database.CacheService, order entities.Order) {
+	keys := []string{"orders:id:" + strconv.Itoa(int(order.ID))}
+	for _, productID := range order.ProductIDs() {
+		keys = append(keys, "orders:productid:"+strconv.Itoa(int(productID)))
+	}
+
+	cache.Del(ctx, keys...)
+}
+
+// newOutboxEvent menyiapkan event yang akan dikirim oleh OutboxRelay
+// setelah transaksi yang menulisnya berhasil di-commit. ID event sekaligus
+// menjadi message ID, dan correlation ID diambil dari ctx.
+func newOutboxEvent(ctx context.Context, exchange, eventType string, data interface{}) (entities.OutboxEvent, error) {
+	env, err := events.New(eventType, data, helpers.CorrelationIDFromContext(ctx))
 	if err != nil {
-		log.Fatalf("Failed to open a channel: %v", err)
+		return entities.OutboxEvent{}, err
 	}
-	defer ch.Close()
 
-	err = ch.Qos(
-		10,    // prefetchCount
-		0,     // prefetchSize
-		false, // global
-	)
+	contentType, body, err := events.Marshal(env)
 	if err != nil {
-		log.Fatalf("Failed to set QoS: %v", err)
+		return entities.OutboxEvent{}, err
 	}
 
-	msgs, err := s.messaging.Consume("order-service.order.requests", "order.created.request")
+	return entities.OutboxEvent{
+		ID:            env.ID,
+		Exchange:      exchange,
+		RoutingKey:    eventType,
+		PartitionKey:  events.PartitionKey(data),
+		ContentType:   contentType,
+		Payload:       body,
+		Status:        "pending",
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// StartOrderConsumer memproses message sampai ctx dibatalkan. Message yang
+// sedang diproses selalu diselesaikan (Ack/Nack) sebelum consumer berhenti.
+func (s *orderService) StartOrderConsumer(ctx context.Context) {
+	msgs, err := s.messaging.Consume(ctx, orderRequestQueue, "order.created.request")
 	if err != nil {
 		log.Fatalf("Failed to register consumer: %v", err)
 	}
 
 	log.Println("Order consumer started, waiting for messages...")
 
-	for d := range msgs {
-		s.processMessage(d)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Order consumer stopped")
+			return
+		case d, ok := <-msgs:
+			if !ok {
+				return
+			}
+			s.processMessage(ctx, d)
+		}
 	}
 }
 
-func (s *orderService) processMessage(d amqp091.Delivery) {
+func (s *orderService) processMessage(ctx context.Context, d messaging.Message) {
+	// Delivery yang sudah diterima tetap diselesaikan walaupun consumer dihentikan.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), messageTimeout)
+	defer cancel()
+
+	var orderRequest events.OrderCreatedRequest
+
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("ERROR: Consumer panicked while processing message: %v", r)
-			if err := d.Nack(false, true); err != nil {
-				log.Printf("Failed to Nack message on panic: %v", err)
-			}
+			s.retryOrFailOrderID(ctx, d, orderRequest.OrderID, "Order processing failed")
 		}
 	}()
 
-	log.Printf("Received a message from queue: %s", string(d.Body))
+	log.Printf("Received a message from queue: %s (%s)", d.ID, d.ContentType)
 
-	var orderRequest map[string]interface{}
-	if err := json.Unmarshal(d.Body, &orderRequest); err != nil {
-		log.Printf("Failed to unmarshal message: %v", err)
-		d.Ack(false) // Acknowledge and drop invalid message
+	env, err := events.Decode(d.ContentType, d.Body, events.TypeOrderCreatedRequest, &orderRequest)
+	if err != nil {
+		log.Printf("Failed to decode message: %v", err)
+		d.Nack(false) // Park invalid message in DLQ
 		return
 	}
 
-	productID := uint(orderRequest["productID"].(float64))
-	qty := int(orderRequest["qty"].(float64))
+	ctx = helpers.WithCorrelationID(ctx, env.CorrelationID)
 
-	productURL := fmt.Sprintf("%s/products/%d", os.Getenv("PRODUCT_SERVICE_URL"), productID)
-	resp, err := s.httpClient.Get(productURL)
+	processed, err := s.alreadyProcessed(ctx, d)
 	if err != nil {
-		log.Printf("Failed to call product-service: %v", err)
-		d.Nack(false, true) // Requeue
+		log.Printf("Failed to check processed message: %v", err)
+		s.retryOrFailOrderID(ctx, d, orderRequest.OrderID, "Order processing failed")
+		return
+	}
+
+	if processed {
+		log.Printf("Message %s already processed, skipping redelivery", d.ID)
+		d.Ack()
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Product not found or invalid response status: %d", resp.StatusCode)
+	orderID := orderRequest.OrderID
 
-		eventPayload := map[string]interface{}{
-			"productID": productID,
-			"qty":       qty,
-			"reason":    "Product not found",
-			"timestamp": time.Now(),
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Order ID %d not found, dropping message", orderID)
+			d.Ack()
+			return
 		}
-		jsonData, _ := json.Marshal(eventPayload)
-		s.messaging.PublishEvent(os.Getenv("RABBITMQ_EXCHANGE_NAME"), "order.failed", jsonData)
 
-		d.Ack(false)
+		log.Printf("Failed to load order from DB: %v", err)
+		s.retryOrFailOrderID(ctx, d, orderID, "Order processing failed")
+		return
+	}
+
+	if order.Status != entities.OrderStatusPending {
+		log.Printf("Order ID %d already %s, skipping", order.ID, order.Status)
+		d.Ack()
 		return
 	}
 
-	var productResp ProductResponse
-	if err := json.NewDecoder(resp.Body).Decode(&productResp); err != nil {
+	// Harga semua item diambil dulu; order hanya di-commit jika setiap produk
+	// tersedia. Dengan saga, stok dipesan lewat stock.reserve sehingga dua
+	// order yang bersamaan tidak bisa sama-sama lolos.
+	products, err := s.productClient.GetProducts(ctx, order.ProductIDs())
+	switch {
+	case errors.Is(err, clients.ErrInvalidProductResponse):
 		log.Printf("Failed to decode product data: %v", err)
+		s.failOrderAndAck(ctx, d, order, "Invalid product data")
+		return
+	case err != nil:
+		log.Printf("Failed to call product-service: %v", err)
+		s.retryOrFailOrder(ctx, d, order, "Product service unavailable")
+		return
+	}
+
+	items := make([]entities.OrderItem, 0, len(order.Items))
+	var totalPrice float64
+	for _, item := range order.Items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			log.Printf("Product ID %d not found for order ID %d", item.ProductID, order.ID)
+			s.failOrderAndAck(ctx, d, order, fmt.Sprintf("Product %d not found", item.ProductID))
+			return
+		}
+
+		if !s.stockSaga && product.Qty < item.Qty {
+			log.Printf("Insufficient stock for product ID: %d", item.ProductID)
+			s.failOrderAndAck(ctx, d, order, fmt.Sprintf("Insufficient stock for product %d", item.ProductID))
+			return
+		}
+
+		item.UnitPrice = product.Price
+		item.Subtotal = product.Price * float64(item.Qty)
+		totalPrice += item.Subtotal
+		items = append(items, item)
+	}
+
+	var updatedOrder entities.Order
+	err = s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		if err := markMessageProcessed(ctx, repos, d.ID, orderRequestQueue); err != nil {
+			return err
+		}
+
+		if _, err := lockPendingOrder(ctx, repos, order.ID); err != nil {
+			return err
+		}
+
+		if err := repos.Orders.UpdateItems(ctx, items); err != nil {
+			return err
+		}
+
+		if !s.stockSaga {
+			order.TotalPrice = totalPrice
 
-		d.Nack(false, false) // Nack without requeue
+			var err error
+			updatedOrder, err = completeOrder(ctx, repos, s.exchange, order, "", false)
+			return err
+		}
+
+		if !order.Status.CanTransitionTo(entities.OrderStatusAwaitingStock) {
+			return fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, order.Status, entities.OrderStatusAwaitingStock)
+		}
+
+		var err error
+		updatedOrder, err = repos.Orders.Update(ctx, entities.Order{
+			ID:         order.ID,
+			Status:     entities.OrderStatusAwaitingStock,
+			TotalPrice: totalPrice,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordStatusChange(ctx, repos, order.ID, order.Status, updatedOrder.Status, ""); err != nil {
+			return err
+		}
+
+		return publishStockEvent(ctx, repos, s.exchange, events.TypeStockReserve, updatedOrder)
+	})
+	if errors.Is(err, errOrderNotPending) {
+		log.Printf("Order ID %d changed while processing, skipping: %v", order.ID, err)
+		d.Ack()
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to update order in DB: %v", err)
+		s.retryOrFailOrder(ctx, d, order, "Order processing failed")
 		return
 	}
 
-	if productResp.Data.Qty < qty {
-		log.Printf("Insufficient stock for product ID: %d", productID)
+	invalidateOrderCache(ctx, s.cache, updatedOrder)
+
+	d.Ack() // Acknowledge message after successful processing
+	if s.stockSaga {
+		log.Printf("Requested stock reservation for order ID: %d", updatedOrder.ID)
+	} else {
+		log.Printf("Successfully processed order ID: %d", updatedOrder.ID)
+	}
+}
+
+// completeOrder mengubah order menjadi completed dan menulis satu event
+// order.created per item ke outbox. stockReserved menandai bahwa stok sudah
+// diambil oleh reservasi saga, sehingga product-service tidak boleh
+// menguranginya lagi. Harus dipanggil di dalam transaksi.
+func completeOrder(ctx context.Context, repos repositories.Repositories, exchange string, order entities.Order, reason string, stockReserved bool) (entities.Order, error) {
+	if !order.Status.CanTransitionTo(entities.OrderStatusCompleted) {
+		return entities.Order{}, fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, order.Status, entities.OrderStatusCompleted)
+	}
+
+	completedOrder, err := repos.Orders.Update(ctx, entities.Order{
+		ID:         order.ID,
+		Status:     entities.OrderStatusCompleted,
+		TotalPrice: order.TotalPrice,
+	})
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	if err := recordStatusChange(ctx, repos, order.ID, order.Status, completedOrder.Status, reason); err != nil {
+		return entities.Order{}, err
+	}
 
-		eventPayload := map[string]interface{}{
-			"productID": productID,
-			"qty":       qty,
-			"reason":    "Insufficient stock",
-			"timestamp": time.Now(),
+	// Satu event per item, sesuai payload yang dipakai reduceStock di product-service.
+	for _, item := range completedOrder.Items {
+		event, err := newOutboxEvent(ctx, exchange, events.TypeOrderCreated, events.OrderCreated{
+			OrderID:       completedOrder.ID,
+			ProductID:     item.ProductID,
+			Qty:           item.Qty,
+			StockReserved: stockReserved,
+		})
+		if err != nil {
+			return entities.Order{}, err
+		}
+
+		if err := repos.Outbox.Create(ctx, event); err != nil {
+			return entities.Order{}, err
 		}
-		jsonData, _ := json.Marshal(eventPayload)
-		s.messaging.PublishEvent(os.Getenv("RABBITMQ_EXCHANGE_NAME"), "order.failed", jsonData)
+	}
+
+	return completedOrder, nil
+}
 
-		d.Ack(false)
+// failOrderAndAck menandai order failed lalu meng-ack message. Jika gagal
+// menyimpan, message dijadwalkan ulang.
+func (s *orderService) failOrderAndAck(ctx context.Context, d messaging.Message, order entities.Order, reason string) {
+	if err := s.failOrder(ctx, d.ID, order, reason); err != nil {
+		log.Printf("Failed to mark order as failed: %v", err)
+		s.retryOrFailOrder(ctx, d, order, reason)
 		return
 	}
 
-	order := entities.Order{
-		ProductID:  productID,
-		Qty:        qty,
-		Status:     "completed",
-		TotalPrice: productResp.Data.Price * float64(qty),
+	d.Ack()
+}
+
+// alreadyProcessed memeriksa apakah message dengan ID yang sama sudah pernah
+// diproses sebelumnya.
+func (s *orderService) alreadyProcessed(ctx context.Context, d messaging.Message) (bool, error) {
+	if d.ID == "" {
+		return false, nil
 	}
 
-	createdOrder, err := s.orderRepo.Create(order)
+	return s.processedMessageRepo.Exists(ctx, d.ID)
+}
+
+// retryOrFailOrder menjadwalkan ulang message. Jika retry sudah habis, order
+// ditandai failed agar bisa di-replay lewat admin API.
+func (s *orderService) retryOrFailOrder(ctx context.Context, d messaging.Message, order entities.Order, reason string) {
+	if messaging.RetryCount(d) < len(messaging.RetryDelays) {
+		s.retryMessage(ctx, orderRequestQueue, d)
+		return
+	}
+
+	if err := s.failOrder(ctx, d.ID, order, reason); err != nil {
+		log.Printf("Failed to mark order as failed: %v", err)
+		d.Nack(false) // Park in DLQ
+		return
+	}
+
+	d.Ack()
+}
+
+// retryOrFailOrderID sama dengan retryOrFailOrder untuk error yang terjadi
+// sebelum order dimuat. Saat retry habis, order dibaca ulang dan hanya
+// ditandai failed jika masih pending.
+func (s *orderService) retryOrFailOrderID(ctx context.Context, d messaging.Message, orderID uint, reason string) {
+	if messaging.RetryCount(d) < len(messaging.RetryDelays) {
+		s.retryMessage(ctx, orderRequestQueue, d)
+		return
+	}
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
 	if err != nil {
-		log.Printf("Failed to create order in DB: %v", err)
-		d.Nack(false, true) // Requeue
+		log.Printf("Failed to load order ID %d after exhausting retries: %v", orderID, err)
+		d.Nack(false) // Park in DLQ
 		return
 	}
 
-	s.cache.Del("orders:id:" + strconv.Itoa(int(createdOrder.ID)))
-	s.cache.Del("orders:productid:" + strconv.Itoa(int(createdOrder.ProductID)))
+	if order.Status != entities.OrderStatusPending {
+		d.Ack()
+		return
+	}
 
-	eventPayload := map[string]interface{}{
-		"pattern": "order.created",
-		"data": map[string]interface{}{
-			"orderID":   createdOrder.ID,
-			"productID": createdOrder.ProductID,
-			"qty":       createdOrder.Qty,
-		},
+	s.retryOrFailOrder(ctx, d, order, reason)
+}
+
+// retryMessage menjadwalkan ulang message yang gagal diproses karena error
+// sementara. Setelah RetryDelays habis, message diparkir di DLQ.
+func (s *orderService) retryMessage(ctx context.Context, queueName string, d messaging.Message) {
+	retryDelivery(ctx, s.messaging, queueName, d)
+}
+
+func retryDelivery(ctx context.Context, msg messaging.MessagingService, queueName string, d messaging.Message) {
+	attempt := messaging.RetryCount(d) + 1
+	if attempt > len(messaging.RetryDelays) {
+		log.Printf("Message %s exhausted %d retries, parking in DLQ", d.ID, len(messaging.RetryDelays))
+		d.Nack(false)
+		return
+	}
+
+	if err := msg.Retry(ctx, queueName, d, attempt); err != nil {
+		log.Printf("Failed to schedule retry for message %s: %v", d.ID, err)
+		d.Nack(true) // Requeue
+		return
+	}
+
+	d.Ack()
+}
+
+// failOrder menandai order sebagai failed dan mencatat event order.failed
+// di outbox dalam transaksi yang sama. Order yang sudah tidak pending
+// dibiarkan apa adanya.
+func (s *orderService) failOrder(ctx context.Context, messageID string, order entities.Order, reason string) error {
+	var failedOrder entities.Order
+	err := s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		if err := markMessageProcessed(ctx, repos, messageID, orderRequestQueue); err != nil {
+			return err
+		}
+
+		currentOrder, err := lockPendingOrder(ctx, repos, order.ID)
+		if err != nil {
+			return err
+		}
+
+		failedOrder, err = markOrderFailed(ctx, repos, s.exchange, currentOrder, reason)
+		return err
+	})
+	if errors.Is(err, errOrderNotPending) {
+		log.Printf("Order ID %d changed while processing, not failing it: %v", order.ID, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	invalidateOrderCache(ctx, s.cache, failedOrder)
+
+	return nil
+}
+
+// markOrderFailed mengubah status order menjadi failed, mencatat history, dan
+// menulis event order.failed ke outbox. Harus dipanggil di dalam transaksi.
+func markOrderFailed(ctx context.Context, repos repositories.Repositories, exchange string, order entities.Order, reason string) (entities.Order, error) {
+	if !order.Status.CanTransitionTo(entities.OrderStatusFailed) {
+		return entities.Order{}, fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, order.Status, entities.OrderStatusFailed)
+	}
+
+	failedOrder, err := repos.Orders.Update(ctx, entities.Order{
+		ID:     order.ID,
+		Status: entities.OrderStatusFailed,
+	})
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	if err := recordStatusChange(ctx, repos, order.ID, order.Status, failedOrder.Status, reason); err != nil {
+		return entities.Order{}, err
 	}
-	jsonData, _ := json.Marshal(eventPayload)
-	err = s.messaging.PublishEvent(os.Getenv("RABBITMQ_EXCHANGE_NAME"), "order.created", jsonData)
+
+	event, err := newOutboxEvent(ctx, exchange, events.TypeOrderFailed, events.OrderFailed{
+		OrderID: failedOrder.ID,
+		Items:   orderItemsPayload(failedOrder.Items),
+		Reason:  reason,
+	})
 	if err != nil {
-		log.Printf("Failed to publish RabbitMQ event: %v", err)
+		return entities.Order{}, err
 	}
 
-	d.Ack(false) // Acknowledge message after successful processing
-	log.Printf("Successfully processed order ID: %d", createdOrder.ID)
+	if err := repos.Outbox.Create(ctx, event); err != nil {
+		return entities.Order{}, err
+	}
+
+	return failedOrder, nil
 }
 
-func (s *orderService) StartOrderFailedConsumer() {
-    msgs, err := s.messaging.SetupFailedQueue()
-    if err != nil {
-        log.Fatalf("Failed to setup failed queue: %v", err)
-    }
+// lockPendingOrder mengunci order sampai transaksi selesai dan memastikan
+// statusnya masih pending, karena Cancel atau PUT bisa mengubahnya sejak order
+// dibaca consumer tanpa lock.
+func lockPendingOrder(ctx context.Context, repos repositories.Repositories, id uint) (entities.Order, error) {
+	order, err := repos.Orders.FindByIDForUpdate(ctx, id)
+	if err != nil {
+		return entities.Order{}, err
+	}
 
-    log.Println("Failed order consumer started, waiting for messages...")
+	if order.Status != entities.OrderStatusPending {
+		return entities.Order{}, fmt.Errorf("%w: order is %s", errOrderNotPending, order.Status)
+	}
 
-    for d := range msgs {
-        log.Printf("Received failed order: %s", string(d.Body))
-        d.Ack(false)
-    }
+	return order, nil
+}
+
+// recordStatusChange mencatat perubahan status ke order_status_history dalam
+// transaksi yang sama dengan perubahan order-nya.
+func recordStatusChange(ctx context.Context, repos repositories.Repositories, orderID uint, oldStatus, newStatus entities.OrderStatus, reason string) error {
+	return repos.StatusHistory.Create(ctx, entities.OrderStatusHistory{
+		OrderID:   orderID,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Reason:    reason,
+		Actor:     helpers.ActorFromContext(ctx),
+	})
+}
+
+// markMessageProcessed mencatat ID message dalam transaksi yang sama dengan
+// perubahan order, sehingga redelivery setelah commit tidak diproses ulang.
+func markMessageProcessed(ctx context.Context, repos repositories.Repositories, messageID, consumer string) error {
+	if messageID == "" {
+		return nil
+	}
+
+	return repos.ProcessedMessages.Create(ctx, messageID, consumer)
+}
+
+func (s *orderService) StartOrderFailedConsumer(ctx context.Context) {
+	msgs, err := s.messaging.SetupFailedQueue(ctx)
+	if err != nil {
+		log.Fatalf("Failed to setup failed queue: %v", err)
+	}
+
+	log.Println("Failed order consumer started, waiting for messages...")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Failed order consumer stopped")
+			return
+		case d, ok := <-msgs:
+			if !ok {
+				return
+			}
+			s.processFailedMessage(ctx, d)
+		}
+	}
+}
+
+// processFailedMessage menyimpan event order.failed agar bisa diperiksa dan
+// di-replay lewat admin API.
+func (s *orderService) processFailedMessage(ctx context.Context, d messaging.Message) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), messageTimeout)
+	defer cancel()
+
+	log.Printf("Received failed order: %s (%s)", d.ID, d.ContentType)
+
+	var failedEvent events.OrderFailed
+	env, err := events.Decode(d.ContentType, d.Body, events.TypeOrderFailed, &failedEvent)
+	if err != nil {
+		log.Printf("Failed to decode failed order message: %v", err)
+		d.Nack(false) // Park invalid message in DLQ
+		return
+	}
+
+	// Store the JSON form so the admin API can show protobuf events too
+	payload, err := env.JSON()
+	if err != nil {
+		log.Printf("Failed to marshal failed order event: %v", err)
+		d.Nack(false)
+		return
+	}
+
+	processed, err := s.alreadyProcessed(ctx, d)
+	if err != nil {
+		log.Printf("Failed to check processed message: %v", err)
+		s.retryMessage(ctx, failedOrderQueue, d)
+		return
+	}
+
+	if processed {
+		log.Printf("Message %s already processed, skipping redelivery", d.ID)
+		d.Ack()
+		return
+	}
+
+	err = s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		if err := markMessageProcessed(ctx, repos, d.ID, failedOrderQueue); err != nil {
+			return err
+		}
+
+		_, err := repos.FailedOrders.Create(ctx, entities.FailedOrder{
+			OrderID:  failedEvent.OrderID,
+			Reason:   failedEvent.Reason,
+			Payload:  string(payload),
+			FailedAt: env.OccurredAt,
+		})
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to store failed order: %v", err)
+		s.retryMessage(ctx, failedOrderQueue, d)
+		return
+	}
+
+	d.Ack()
 }
 
 // func (s *orderService) Create(order entities.Order) (entities.Order, error) {
@@ -255,13 +771,13 @@ func (s *orderService) StartOrderFailedConsumer() {
 
 // 	order.TotalPrice = product.Data.Price * float64(order.Qty)
 
-// 	createdOrder, err := s.orderRepo.Create(order)
+// 	createdOrder, err := s.orderRepo.Create(ctx, order)
 // 	if err != nil {
 // 		return entities.Order{}, err
 // 	}
 
-// 	s.cache.Del("orders:id:" + strconv.Itoa(int(createdOrder.ID)))
-// 	s.cache.Del("orders:productid:" + strconv.Itoa(int(createdOrder.ProductID)))
+// 	s.cache.Del(ctx, "orders:id:" + strconv.Itoa(int(createdOrder.ID)))
+// 	s.cache.Del(ctx, "orders:productid:" + strconv.Itoa(int(createdOrder.ProductID)))
 
 // 	eventPayload := map[string]interface{}{
 // 		"pattern": "order.created",
@@ -278,62 +794,408 @@ func (s *orderService) StartOrderFailedConsumer() {
 // 	return createdOrder, nil
 // }
 
-func (s *orderService) FindAll() ([]entities.Order, error) {
-	return s.orderRepo.FindAll()
+func (s *orderService) FindAll(ctx context.Context, filter entities.OrderFilter) (entities.OrderPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageLimit
+	}
+	limit := filter.Limit
+
+	total, err := s.orderRepo.Count(ctx, filter)
+	if err != nil {
+		return entities.OrderPage{}, err
+	}
+
+	// Ambil satu baris ekstra untuk mengetahui apakah masih ada halaman berikutnya.
+	filter.Limit = limit + 1
+	orders, err := s.orderRepo.FindAll(ctx, filter)
+	if err != nil {
+		return entities.OrderPage{}, err
+	}
+
+	page := entities.OrderPage{
+		Orders: orders,
+		Total:  total,
+		Limit:  limit,
+		Offset: filter.Offset,
+	}
+
+	if filter.Cursor != nil {
+		page.Offset = 0
+	}
+
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		page.HasMore = true
+
+		last := page.Orders[limit-1]
+		page.NextCursor = entities.OrderCursor{ID: last.ID, CreatedAt: last.CreatedAt}.Encode()
+	}
+
+	return page, nil
 }
 
-func (s *orderService) FindByID(id uint) (entities.Order, error) {
+func (s *orderService) FindByID(ctx context.Context, id uint) (entities.Order, error) {
 	cacheKey := fmt.Sprintf("orders:id:%d", id)
-	val, err := s.cache.Get(cacheKey)
+	val, err := s.cache.Get(ctx, cacheKey)
 	if err == nil && val != "" {
 		var order entities.Order
 		json.Unmarshal([]byte(val), &order)
 		return order, nil
 	}
 
-	order, err := s.orderRepo.FindByID(id)
+	order, err := s.orderRepo.FindByID(ctx, id)
 	if err != nil {
 		return entities.Order{}, err
 	}
 
 	jsonData, _ := json.Marshal(order)
-	s.cache.SetWithTTL(cacheKey, string(jsonData), cacheTTL)
+	s.cache.SetWithTTL(ctx, cacheKey, string(jsonData), cacheTTL)
 	return order, nil
 }
 
-func (s *orderService) FindByProductID(productID uint) ([]entities.Order, error) {
+func (s *orderService) FindByProductID(ctx context.Context, productID uint) ([]entities.Order, error) {
 	cacheKey := fmt.Sprintf("orders:productid:%d", productID)
-	val, err := s.cache.Get(cacheKey)
+	val, err := s.cache.Get(ctx, cacheKey)
 	if err == nil && val != "" {
 		var orders []entities.Order
 		json.Unmarshal([]byte(val), &orders)
 		return orders, nil
 	}
 
-	orders, err := s.orderRepo.FindByProductID(productID)
+	orders, err := s.orderRepo.FindByProductID(ctx, productID)
 	if err != nil {
 		return nil, err
 	}
 
 	jsonData, _ := json.Marshal(orders)
-	s.cache.SetWithTTL(cacheKey, string(jsonData), cacheTTL)
+	s.cache.SetWithTTL(ctx, cacheKey, string(jsonData), cacheTTL)
 	return orders, nil
 }
 
-func (s *orderService) Update(order entities.Order) (entities.Order, error) {
-	updatedOrder, err := s.orderRepo.Update(order)
+// Update hanya mengizinkan perpindahan status yang tercantum di
+// entities.OrderStatus.CanTransitionManuallyTo. Order yang dibuat failed
+// melewati markOrderFailed, sehingga order.failed tetap terkirim.
+func (s *orderService) Update(ctx context.Context, order entities.Order, reason string) (entities.Order, error) {
+	if !order.Status.IsValid() {
+		return entities.Order{}, fmt.Errorf("%w: %q", ErrInvalidOrderStatus, order.Status)
+	}
+
+	var updatedOrder entities.Order
+	err := s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		currentOrder, err := repos.Orders.FindByIDForUpdate(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		// Status with side effects goes through Cancel, the stock saga or replay
+		if !currentOrder.Status.CanTransitionManuallyTo(order.Status) {
+			return fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, currentOrder.Status, order.Status)
+		}
+
+		if order.Status == entities.OrderStatusFailed {
+			if reason == "" {
+				reason = "Failed by request"
+			}
+
+			updatedOrder, err = markOrderFailed(ctx, repos, s.exchange, currentOrder, reason)
+			return err
+		}
+
+		updatedOrder, err = repos.Orders.Update(ctx, order)
+		if err != nil {
+			return err
+		}
+
+		return recordStatusChange(ctx, repos, order.ID, currentOrder.Status, updatedOrder.Status, reason)
+	})
 	if err != nil {
 		return entities.Order{}, err
 	}
 
-	s.cache.Del("orders:id:" + strconv.Itoa(int(updatedOrder.ID)))
-	s.cache.Del("orders:productid:" + strconv.Itoa(int(updatedOrder.ProductID)))
+	invalidateOrderCache(ctx, s.cache, updatedOrder)
 
 	return updatedOrder, nil
 }
 
-func (s *orderService) Delete(id uint) error {
-	s.cache.Del("orders:id:" + strconv.Itoa(int(id)))
+// Cancel membatalkan order dan mengirim event order.cancelled. Restock hanya
+// bernilai true jika stok sudah dikurangi, yaitu saat order sudah completed.
+func (s *orderService) Cancel(ctx context.Context, id uint) (entities.Order, error) {
+	var cancelledOrder entities.Order
+	err := s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		order, err := repos.Orders.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !order.Status.CanTransitionTo(entities.OrderStatusCancelled) {
+			return fmt.Errorf("%w: order is %s", ErrOrderNotCancellable, order.Status)
+		}
+
+		cancelledOrder, err = repos.Orders.Update(ctx, entities.Order{ID: order.ID, Status: entities.OrderStatusCancelled})
+		if err != nil {
+			return err
+		}
+
+		if err := recordStatusChange(ctx, repos, order.ID, order.Status, cancelledOrder.Status, "Cancelled by request"); err != nil {
+			return err
+		}
+
+		// Satu event per item, sama seperti order.created.
+		for _, item := range cancelledOrder.Items {
+			event, err := newOutboxEvent(ctx, s.exchange, events.TypeOrderCancelled, events.OrderCancelled{
+				OrderID:   cancelledOrder.ID,
+				ProductID: item.ProductID,
+				Qty:       item.Qty,
+				Restock:   order.Status == entities.OrderStatusCompleted,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := repos.Outbox.Create(ctx, event); err != nil {
+				return err
+			}
+		}
+
+		// Reservasi yang mungkin sudah dibuat product-service dilepas lagi.
+		if order.Status == entities.OrderStatusAwaitingStock {
+			return publishStockEvent(ctx, repos, s.exchange, events.TypeStockRelease, cancelledOrder)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	invalidateOrderCache(ctx, s.cache, cancelledOrder)
+
+	return cancelledOrder, nil
+}
+
+// Delete melakukan soft delete, sehingga data order tetap tersimpan untuk
+// keperluan pencatatan dan bisa dikembalikan lewat Restore. Order yang masih
+// diproses ditolak, karena consumer dan sweeper tidak melihat order terhapus
+// sehingga reservasi stoknya tidak pernah dilepas.
+func (s *orderService) Delete(ctx context.Context, id uint) error {
+	var order entities.Order
+	err := s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		var err error
+		order, err = repos.Orders.FindByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if order.Status.IsInProgress() {
+			return fmt.Errorf("%w: order is %s", ErrOrderNotDeletable, order.Status)
+		}
+
+		return repos.Orders.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	invalidateOrderCache(ctx, s.cache, order)
+
+	return nil
+}
+
+func (s *orderService) Restore(ctx context.Context, id uint) (entities.Order, error) {
+	if err := s.orderRepo.Restore(ctx, id); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return entities.Order{}, err
+		}
+
+		// Bedakan order yang tidak ada dengan order yang memang belum dihapus.
+		if _, findErr := s.orderRepo.FindByID(ctx, id); findErr != nil {
+			return entities.Order{}, findErr
+		}
+
+		return entities.Order{}, ErrOrderNotDeleted
+	}
+
+	restoredOrder, err := s.orderRepo.FindByID(ctx, id)
+	if err != nil {
+		return entities.Order{}, err
+	}
+
+	invalidateOrderCache(ctx, s.cache, restoredOrder)
+
+	return restoredOrder, nil
+}
+
+func (s *orderService) FindHistory(ctx context.Context, orderID uint) ([]entities.OrderStatusHistory, error) {
+	if _, err := s.orderRepo.FindByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	return s.statusHistoryRepo.FindByOrderID(ctx, orderID)
+}
+
+// StartStockReplyConsumer memproses balasan stock.reserved dan stock.rejected
+// dari product-service sampai ctx dibatalkan.
+func (s *orderService) StartStockReplyConsumer(ctx context.Context) {
+	reserved, err := s.messaging.Consume(ctx, stockReservedQueue, "stock.reserved")
+	if err != nil {
+		log.Fatalf("Failed to register stock.reserved consumer: %v", err)
+	}
+
+	rejected, err := s.messaging.Consume(ctx, stockRejectedQueue, "stock.rejected")
+	if err != nil {
+		log.Fatalf("Failed to register stock.rejected consumer: %v", err)
+	}
+
+	log.Println("Stock reply consumer started, waiting for messages...")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stock reply consumer stopped")
+			return
+		case d, ok := <-reserved:
+			if !ok {
+				return
+			}
+			s.processStockReply(ctx, stockReservedQueue, d)
+		case d, ok := <-rejected:
+			if !ok {
+				return
+			}
+			s.processStockReply(ctx, stockRejectedQueue, d)
+		}
+	}
+}
+
+// processStockReply menyelesaikan saga reservasi stok. stock.reserved membuat
+// order completed, stock.rejected membuat order failed. stock.reserved untuk
+// order yang sudah tidak awaiting_stock hanya dikompensasi dengan
+// stock.release jika reservasinya tidak pernah dipakai; lihat reservationUnused.
+func (s *orderService) processStockReply(ctx context.Context, queueName string, d messaging.Message) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), messageTimeout)
+	defer cancel()
+
+	log.Printf("Received stock reply: %s (%s)", d.ID, d.ContentType)
+
+	reservedStock := queueName == stockReservedQueue
+
+	eventType := events.TypeStockRejected
+	if reservedStock {
+		eventType = events.TypeStockReserved
+	}
+
+	var reply events.StockReply
+	env, err := events.Decode(d.ContentType, d.Body, eventType, &reply)
+	if err != nil {
+		log.Printf("Failed to decode stock reply: %v", err)
+		d.Nack(false) // Park invalid message in DLQ
+		return
+	}
+
+	ctx = helpers.WithCorrelationID(ctx, env.CorrelationID)
+
+	processed, err := s.alreadyProcessed(ctx, d)
+	if err != nil {
+		log.Printf("Failed to check processed message: %v", err)
+		s.retryMessage(ctx, queueName, d)
+		return
+	}
+
+	if processed {
+		log.Printf("Message %s already processed, skipping redelivery", d.ID)
+		d.Ack()
+		return
+	}
+
+	var updatedOrder entities.Order
+	err = s.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		if err := markMessageProcessed(ctx, repos, d.ID, queueName); err != nil {
+			return err
+		}
+
+		order, err := repos.Orders.FindByIDForUpdate(ctx, reply.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if order.Status != entities.OrderStatusAwaitingStock {
+			log.Printf("Order ID %d already %s, ignoring stock reply", order.ID, order.Status)
+			if !reservedStock {
+				return nil
+			}
+
+			unused, err := reservationUnused(ctx, repos, order)
+			if err != nil || !unused {
+				return err
+			}
+
+			return publishStockEvent(ctx, repos, s.exchange, events.TypeStockRelease, order)
+		}
+
+		if !reservedStock {
+			reason := reply.Reason
+			if reason == "" {
+				reason = "Stock reservation rejected"
+			}
+
+			updatedOrder, err = markOrderFailed(ctx, repos, s.exchange, order, reason)
+			return err
+		}
+
+		updatedOrder, err = completeOrder(ctx, repos, s.exchange, order, "Stock reserved", true)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Order ID %d not found, dropping stock reply", reply.OrderID)
+		d.Ack()
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to apply stock reply: %v", err)
+		s.retryMessage(ctx, queueName, d)
+		return
+	}
+
+	if updatedOrder.ID != 0 {
+		invalidateOrderCache(ctx, s.cache, updatedOrder)
+	}
+
+	d.Ack()
+}
+
+// reservationUnused melaporkan apakah stok yang dipesan untuk order boleh
+// dilepas: order harus failed atau cancelled dan tidak pernah completed.
+// stock.reserved ganda untuk order yang sudah completed tidak boleh melepas
+// stok yang sudah dipakai.
+func reservationUnused(ctx context.Context, repos repositories.Repositories, order entities.Order) (bool, error) {
+	if order.Status != entities.OrderStatusFailed && order.Status != entities.OrderStatusCancelled {
+		return false, nil
+	}
+
+	history, err := repos.StatusHistory.FindByOrderID(ctx, order.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, change := range history {
+		if change.NewStatus == entities.OrderStatusCompleted {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// publishStockEvent menulis perintah saga stok (stock.reserve atau
+// stock.release) untuk seluruh item order ke outbox.
+func publishStockEvent(ctx context.Context, repos repositories.Repositories, exchange, eventType string, order entities.Order) error {
+	event, err := newOutboxEvent(ctx, exchange, eventType, events.StockRequest{
+		OrderID: order.ID,
+		Items:   orderItemsPayload(order.Items),
+	})
+	if err != nil {
+		return err
+	}
 
-	return s.orderRepo.Delete(id)
+	return repos.Outbox.Create(ctx, event)
 }
@@ -1,16 +1,23 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"order-service/clients"
 	"order-service/entities"
+	"order-service/events"
+	"order-service/helpers"
+	"order-service/messaging"
 	"order-service/mocks"
+	"order-service/repositories"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 func TestOrderService_FindByProductID(t *testing.T) {
@@ -19,8 +26,8 @@ func TestOrderService_FindByProductID(t *testing.T) {
 	cacheTTL := 5 * time.Minute
 
 	expectedOrders := []entities.Order{
-		{ID: 1, ProductID: productID, Qty: 2, Status: "completed"},
-		{ID: 2, ProductID: productID, Qty: 1, Status: "completed"},
+		{ID: 1, Items: []entities.OrderItem{{ProductID: productID, Qty: 2}}, Status: "completed"},
+		{ID: 2, Items: []entities.OrderItem{{ProductID: productID, Qty: 1}}, Status: "completed"},
 	}
 	jsonOrders, _ := json.Marshal(expectedOrders)
 
@@ -29,18 +36,21 @@ func TestOrderService_FindByProductID(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockOrderRepository(ctrl)
-		mockHTTPClient := mocks.NewMockHTTPClient(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
 		mockMessaging := mocks.NewMockMessagingService(ctrl)
 		mockCache := mocks.NewMockCacheService(ctrl)
-		s := NewOrderService(mockRepo, mockHTTPClient, mockMessaging, mockCache)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
 
 		// Expect get cache success
-		mockCache.EXPECT().Get(cacheKey).Return(string(jsonOrders), nil)
+		mockCache.EXPECT().Get(gomock.Any(), cacheKey).Return(string(jsonOrders), nil)
 
 		// Expect repo should have not been called
-		mockRepo.EXPECT().FindByProductID(gomock.Any()).Times(0)
+		mockRepo.EXPECT().FindByProductID(gomock.Any(), gomock.Any()).Times(0)
 
-		orders, err := s.FindByProductID(productID)
+		orders, err := s.FindByProductID(context.Background(), productID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedOrders, orders)
@@ -51,21 +61,24 @@ func TestOrderService_FindByProductID(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockOrderRepository(ctrl)
-		mockHTTPClient := mocks.NewMockHTTPClient(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
 		mockMessaging := mocks.NewMockMessagingService(ctrl)
 		mockCache := mocks.NewMockCacheService(ctrl)
-		s := NewOrderService(mockRepo, mockHTTPClient, mockMessaging, mockCache)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
 
 		// Expect get cache failed or empty
-		mockCache.EXPECT().Get(cacheKey).Return("", errors.New("cache miss"))
+		mockCache.EXPECT().Get(gomock.Any(), cacheKey).Return("", errors.New("cache miss"))
 
 		// Expect call repo succeeded
-		mockRepo.EXPECT().FindByProductID(productID).Return(expectedOrders, nil)
+		mockRepo.EXPECT().FindByProductID(gomock.Any(), productID).Return(expectedOrders, nil)
 
 		// Expect save data to redis cache
-		mockCache.EXPECT().SetWithTTL(cacheKey, string(jsonOrders), cacheTTL).Return(nil)
+		mockCache.EXPECT().SetWithTTL(gomock.Any(), cacheKey, string(jsonOrders), cacheTTL).Return(nil)
 
-		orders, err := s.FindByProductID(productID)
+		orders, err := s.FindByProductID(context.Background(), productID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedOrders, orders)
@@ -76,25 +89,1672 @@ func TestOrderService_FindByProductID(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockOrderRepository(ctrl)
-		mockHTTPClient := mocks.NewMockHTTPClient(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
 		mockMessaging := mocks.NewMockMessagingService(ctrl)
 		mockCache := mocks.NewMockCacheService(ctrl)
-		s := NewOrderService(mockRepo, mockHTTPClient, mockMessaging, mockCache)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
 
 		expectedErr := errors.New("db connection error")
 
 		// Expect get cache failed
-		mockCache.EXPECT().Get(cacheKey).Return("", errors.New("cache miss"))
+		mockCache.EXPECT().Get(gomock.Any(), cacheKey).Return("", errors.New("cache miss"))
 
 		// Expect call repo failed
-		mockRepo.EXPECT().FindByProductID(productID).Return([]entities.Order{}, expectedErr)
+		mockRepo.EXPECT().FindByProductID(gomock.Any(), productID).Return([]entities.Order{}, expectedErr)
 
 		// Expect set cache should not have been called
-		mockCache.EXPECT().SetWithTTL(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockCache.EXPECT().SetWithTTL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
-		orders, err := s.FindByProductID(productID)
+		orders, err := s.FindByProductID(context.Background(), productID)
 
 		assert.Nil(t, orders)
 		assert.EqualError(t, err, expectedErr.Error())
 	})
 }
+
+func TestOrderService_FindAll(t *testing.T) {
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	orders := []entities.Order{
+		{ID: 3, Items: []entities.OrderItem{{ProductID: 123, Qty: 1}}, Status: "pending", CreatedAt: createdAt},
+		{ID: 2, Items: []entities.OrderItem{{ProductID: 123, Qty: 1}}, Status: "pending", CreatedAt: createdAt},
+		{ID: 1, Items: []entities.OrderItem{{ProductID: 123, Qty: 1}}, Status: "pending", CreatedAt: createdAt},
+	}
+
+	t.Run("should return next cursor when more orders are available", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		filter := entities.OrderFilter{Status: "pending", SortBy: entities.OrderSortByCreatedAt, SortDesc: true, Limit: 2}
+
+		mockRepo.EXPECT().Count(gomock.Any(), filter).Return(int64(3), nil)
+
+		// Expect one extra row requested to detect the next page
+		expectedFilter := filter
+		expectedFilter.Limit = 3
+		mockRepo.EXPECT().FindAll(gomock.Any(), expectedFilter).Return(orders, nil)
+
+		page, err := s.FindAll(context.Background(), filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, orders[:2], page.Orders)
+		assert.Equal(t, int64(3), page.Total)
+		assert.True(t, page.HasMore)
+
+		cursor, err := entities.DecodeOrderCursor(page.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), cursor.ID)
+		assert.True(t, createdAt.Equal(cursor.CreatedAt))
+	})
+
+	t.Run("should apply default limit and report last page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		mockRepo.EXPECT().Count(gomock.Any(), gomock.Any()).Return(int64(3), nil)
+		mockRepo.EXPECT().FindAll(gomock.Any(), entities.OrderFilter{Limit: defaultPageLimit + 1, Offset: 1}).Return(orders[1:], nil)
+
+		page, err := s.FindAll(context.Background(), entities.OrderFilter{Offset: 1})
+
+		assert.NoError(t, err)
+		assert.Equal(t, orders[1:], page.Orders)
+		assert.Equal(t, defaultPageLimit, page.Limit)
+		assert.Equal(t, 1, page.Offset)
+		assert.False(t, page.HasMore)
+		assert.Empty(t, page.NextCursor)
+	})
+}
+
+func TestOrderService_Create(t *testing.T) {
+	order := entities.Order{Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}}
+
+	t.Run("should persist pending order together with outbox event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		pendingOrder := entities.Order{Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "pending"}
+		createdOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "pending"}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+
+		// Expect pending order and outbox event written in the same transaction
+		mockRepo.EXPECT().Create(gomock.Any(), pendingOrder).Return(createdOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, NewStatus: entities.OrderStatusPending, Reason: "Order created", Actor: "system"}).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.created.request", event.RoutingKey)
+			assert.Equal(t, "pending", event.Status)
+			assert.JSONEq(t, `{"orderID":10,"items":[{"productID":123,"qty":2}]}`, eventData(t, events.TypeOrderCreatedRequest, event.Payload))
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		// Expect nothing published directly, the outbox relay does that
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		result, err := s.Create(context.Background(), order, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, createdOrder, result)
+	})
+
+	t.Run("should return error when transaction fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		expectedErr := errors.New("db connection error")
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+
+		// Expect call repo failed
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(entities.Order{}, expectedErr)
+
+		// Expect no outbox event to be written
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+		result, err := s.Create(context.Background(), order, "")
+
+		assert.Equal(t, entities.Order{}, result)
+		assert.EqualError(t, err, expectedErr.Error())
+	})
+}
+
+func TestOrderService_CreateIdempotent(t *testing.T) {
+	order := entities.Order{Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}}
+	cacheKey := "orders:idempotency:key-1"
+	fingerprint := orderFingerprint(order)
+
+	t.Run("should reserve key, create order and store the result", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		createdOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "pending"}
+		reservation, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+		record, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Order: createdOrder})
+
+		mockCache.EXPECT().SetNX(gomock.Any(), cacheKey, string(reservation), idempotencyReservationTTL).Return(true, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(createdOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		// Expect the result to be stored for later replays
+		mockCache.EXPECT().SetWithTTL(gomock.Any(), cacheKey, string(record), idempotencyTTL).Return(nil)
+
+		result, err := s.Create(context.Background(), order, "key-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, createdOrder, result)
+	})
+
+	t.Run("should keep only the short reservation when the result cannot be stored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		createdOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "pending"}
+
+		// Expect the in-progress reservation to expire long before the stored result would
+		mockCache.EXPECT().SetNX(gomock.Any(), cacheKey, gomock.Any(), idempotencyReservationTTL).Return(true, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(createdOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockCache.EXPECT().Del(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		mockCache.EXPECT().SetWithTTL(gomock.Any(), cacheKey, gomock.Any(), idempotencyTTL).Return(errors.New("redis down"))
+
+		result, err := s.Create(context.Background(), order, "key-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, createdOrder, result)
+		assert.Less(t, idempotencyReservationTTL, idempotencyTTL)
+	})
+
+	t.Run("should replay the stored order for a repeated key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		storedOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "pending"}
+		record, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint, Order: storedOrder})
+
+		mockCache.EXPECT().SetNX(gomock.Any(), cacheKey, gomock.Any(), idempotencyReservationTTL).Return(false, nil)
+		mockCache.EXPECT().Get(gomock.Any(), cacheKey).Return(string(record), nil)
+
+		// Expect no new order to be created
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+
+		result, err := s.Create(context.Background(), order, "key-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, storedOrder, result)
+	})
+
+	t.Run("should reject a repeated key with a different request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		otherOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 999, Qty: 5}}, Status: "pending"}
+		record, _ := json.Marshal(idempotencyRecord{Fingerprint: orderFingerprint(otherOrder), Order: otherOrder})
+
+		mockCache.EXPECT().SetNX(gomock.Any(), cacheKey, gomock.Any(), idempotencyReservationTTL).Return(false, nil)
+		mockCache.EXPECT().Get(gomock.Any(), cacheKey).Return(string(record), nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+
+		result, err := s.Create(context.Background(), order, "key-1")
+
+		assert.Equal(t, entities.Order{}, result)
+		assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+	})
+}
+
+type fakeAcknowledger struct {
+	acked   bool
+	nacked  bool
+	requeue bool
+}
+
+func (a *fakeAcknowledger) Ack() error {
+	a.acked = true
+	return nil
+}
+
+func (a *fakeAcknowledger) Nack(requeue bool) error {
+	a.nacked = true
+	a.requeue = requeue
+	return nil
+}
+
+// eventData memastikan payload outbox adalah envelope bertipe eventType dan
+// mengembalikan isi data-nya.
+func eventData(t *testing.T, eventType string, payload []byte) string {
+	env, err := events.DecodeEnvelope(events.ContentTypeJSON, payload)
+	assert.NoError(t, err)
+	assert.Equal(t, eventType, env.Type)
+	assert.Equal(t, eventType, env.Pattern)
+	assert.NotEmpty(t, env.ID)
+
+	return string(env.Data)
+}
+
+func TestOrderService_ProcessMessage(t *testing.T) {
+	body := []byte(`{"event_id":"evt-1","type":"order.created.request","version":1,"occurred_at":"2025-01-02T03:04:05Z","correlation_id":"corr-1","pattern":"order.created.request","data":{"orderID":10,"items":[{"productID":123,"qty":2}]}}`)
+	pendingOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "pending"}
+
+	t.Run("should ack redelivered message without processing it again", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(true, nil)
+
+		// Expect the order to be left untouched
+		mockRepo.EXPECT().FindByID(gomock.Any(), gomock.Any()).Times(0)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+
+		s.processMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-1", Body: body})
+
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+	})
+
+	t.Run("should park messages with missing fields or unknown versions in DLQ", func(t *testing.T) {
+		bodies := []string{
+			`{"orderID":10}`,
+			`{"pattern":"order.created.request","data":{"items":[{"productID":123,"qty":2}]}}`,
+			`{"version":2,"pattern":"order.created.request","data":{"orderID":10,"items":[{"productID":123,"qty":2}]}}`,
+			`{"pattern":"order.created.request","data":{"orderID":10,"items":[{"productID":123,"qty":2}],"extra":true}}`,
+		}
+
+		for _, b := range bodies {
+			ctrl := gomock.NewController(t)
+
+			mockRepo := mocks.NewMockOrderRepository(ctrl)
+			mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+			mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+			mockTransactor := mocks.NewMockTransactor(ctrl)
+			mockProductClient := mocks.NewMockProductClient(ctrl)
+			mockMessaging := mocks.NewMockMessagingService(ctrl)
+			mockCache := mocks.NewMockCacheService(ctrl)
+			s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+			ack := &fakeAcknowledger{}
+
+			// Expect nothing to be loaded or retried
+			mockRepo.EXPECT().FindByID(gomock.Any(), gomock.Any()).Times(0)
+			mockMessaging.EXPECT().Retry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			s.processMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-1", Body: []byte(b)})
+
+			assert.True(t, ack.nacked, b)
+			assert.False(t, ack.requeue, b)
+			ctrl.Finish()
+		}
+	})
+
+	t.Run("should check stock, complete the order and publish order.created per item without the saga", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		// Expect the saga to be off unless STOCK_RESERVATION_SAGA enables it
+		assert.False(t, s.stockSaga)
+
+		ack := &fakeAcknowledger{}
+		items := []entities.OrderItem{{ID: 1, OrderID: 10, ProductID: 123, Qty: 2}, {ID: 2, OrderID: 10, ProductID: 456, Qty: 1}}
+		completedOrder := entities.Order{ID: 10, Items: items, Status: "completed", TotalPrice: 3500}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: items, Status: "pending"}, nil)
+		mockProductClient.EXPECT().GetProducts(gomock.Any(), []uint{123, 456}).Return(map[uint]clients.Product{
+			123: {ID: 123, Price: 1500, Qty: 10},
+			456: {ID: 456, Price: 500, Qty: 1},
+		}, nil)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-1", orderRequestQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusPending}, nil)
+		mockRepo.EXPECT().UpdateItems(gomock.Any(), gomock.Any()).Return(nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: "completed", TotalPrice: 3500}).Return(completedOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusPending, NewStatus: entities.OrderStatusCompleted, Actor: "system"}).Return(nil)
+
+		// Expect order.created to reduce the stock of each item, with no stock.reserve
+		var payloads []string
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.created", event.RoutingKey)
+			payloads = append(payloads, eventData(t, events.TypeOrderCreated, event.Payload))
+			return nil
+		}).Times(2)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123", "orders:productid:456").Return(nil)
+
+		s.processMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-1", Body: body})
+
+		assert.True(t, ack.acked)
+		assert.JSONEq(t, `{"orderID":10,"productID":123,"qty":2}`, payloads[0])
+		assert.JSONEq(t, `{"orderID":10,"productID":456,"qty":1}`, payloads[1])
+	})
+
+	t.Run("should leave an order cancelled while its products were fetched", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(pendingOrder, nil)
+		mockProductClient.EXPECT().GetProducts(gomock.Any(), []uint{123}).Return(map[uint]clients.Product{
+			123: {ID: 123, Price: 1500, Qty: 10},
+		}, nil)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-1", orderRequestQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusCancelled}, nil)
+
+		// Expect the cancellation to stay and no order.created to be written
+		mockRepo.EXPECT().UpdateItems(gomock.Any(), gomock.Any()).Times(0)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+		mockMessaging.EXPECT().Retry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		s.processMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-1", Body: body})
+
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+	})
+
+	t.Run("should fail the order on insufficient stock without the saga", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+		items := []entities.OrderItem{{ID: 1, OrderID: 10, ProductID: 123, Qty: 2}, {ID: 2, OrderID: 10, ProductID: 456, Qty: 3}}
+		pendingOrder := entities.Order{ID: 10, Items: items, Status: "pending"}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(pendingOrder, nil)
+		mockProductClient.EXPECT().GetProducts(gomock.Any(), []uint{123, 456}).Return(map[uint]clients.Product{
+			123: {ID: 123, Price: 1500, Qty: 10},
+			456: {ID: 456, Price: 500, Qty: 1},
+		}, nil)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-1", orderRequestQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusPending}, nil)
+		mockRepo.EXPECT().UpdateItems(gomock.Any(), gomock.Any()).Times(0)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: "failed"}).Return(entities.Order{ID: 10, Items: items, Status: "failed"}, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusPending, NewStatus: entities.OrderStatusFailed, Reason: "Insufficient stock for product 456", Actor: "system"}).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.failed", event.RoutingKey)
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		s.processMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-1", Body: body})
+
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("should price the items and request a stock reservation in one transaction", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+		s.stockSaga = true
+
+		ack := &fakeAcknowledger{}
+		items := []entities.OrderItem{{ID: 1, OrderID: 10, ProductID: 123, Qty: 2}, {ID: 2, OrderID: 10, ProductID: 456, Qty: 1}}
+		awaitingOrder := entities.Order{ID: 10, Items: items, Status: "awaiting_stock", TotalPrice: 3500}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: items, Status: "pending"}, nil)
+		// Expect catalog quantities to be ignored since the reservation checks stock
+		mockProductClient.EXPECT().GetProducts(gomock.Any(), []uint{123, 456}).Return(map[uint]clients.Product{
+			123: {ID: 123, Price: 1500, Qty: 10},
+			456: {ID: 456, Price: 500, Qty: 0},
+		}, nil)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-1", orderRequestQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusPending}, nil)
+		mockRepo.EXPECT().UpdateItems(gomock.Any(), []entities.OrderItem{
+			{ID: 1, OrderID: 10, ProductID: 123, Qty: 2, UnitPrice: 1500, Subtotal: 3000},
+			{ID: 2, OrderID: 10, ProductID: 456, Qty: 1, UnitPrice: 500, Subtotal: 500},
+		}).Return(nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: "awaiting_stock", TotalPrice: 3500}).Return(awaitingOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusPending, NewStatus: entities.OrderStatusAwaitingStock, Actor: "system"}).Return(nil)
+
+		// Expect a single stock.reserve request covering every item
+		var payload string
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "stock.reserve", event.RoutingKey)
+			payload = eventData(t, events.TypeStockReserve, event.Payload)
+
+			// Expect the correlation ID of the request to be carried over
+			env, _ := events.DecodeEnvelope(event.ContentType, event.Payload)
+			assert.Equal(t, "corr-1", env.CorrelationID)
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123", "orders:productid:456").Return(nil)
+
+		s.processMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-1", Body: body})
+
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+		assert.JSONEq(t, `{"orderID":10,"items":[{"productID":123,"qty":2},{"productID":456,"qty":1}]}`, payload)
+	})
+
+	t.Run("should fail the whole order when one product does not exist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+		items := []entities.OrderItem{{ID: 1, OrderID: 10, ProductID: 123, Qty: 2}, {ID: 2, OrderID: 10, ProductID: 456, Qty: 5}}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: items, Status: "pending"}, nil)
+		mockProductClient.EXPECT().GetProducts(gomock.Any(), []uint{123, 456}).Return(map[uint]clients.Product{
+			123: {ID: 123, Price: 1500, Qty: 10},
+		}, nil)
+
+		// Expect no item to be priced and the order to be failed
+		mockRepo.EXPECT().UpdateItems(gomock.Any(), gomock.Any()).Times(0)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-1", orderRequestQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusPending}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: "failed"}).
+			Return(entities.Order{ID: 10, Items: items, Status: "failed"}, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.failed", event.RoutingKey)
+			assert.Contains(t, string(event.Payload), `"reason":"Product 456 not found"`)
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123", "orders:productid:456").Return(nil)
+
+		s.processMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-1", Body: body})
+
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+	})
+
+	t.Run("should schedule a retry when product-service is unreachable", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+		d := messaging.Message{
+			Acknowledger: ack,
+			ID:           "msg-1",
+			Headers:      map[string]interface{}{messaging.RetryCountHeader: int32(1)},
+			Body:         body,
+		}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(pendingOrder, nil)
+		mockProductClient.EXPECT().GetProducts(gomock.Any(), []uint{123}).Return(nil, fmt.Errorf("%w: connection refused", clients.ErrProductServiceUnavailable))
+
+		// Expect the next attempt to be scheduled
+		mockMessaging.EXPECT().Retry(gomock.Any(), orderRequestQueue, gomock.Any(), 2).Return(nil)
+
+		s.processMessage(context.Background(), d)
+
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+	})
+
+	t.Run("should fail the order after retries are exhausted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+		d := messaging.Message{
+			Acknowledger: ack,
+			ID:           "msg-1",
+			Headers:      map[string]interface{}{messaging.RetryCountHeader: int32(len(messaging.RetryDelays))},
+			Body:         body,
+		}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(pendingOrder, nil)
+		mockProductClient.EXPECT().GetProducts(gomock.Any(), []uint{123}).Return(nil, fmt.Errorf("%w: connection refused", clients.ErrProductServiceUnavailable))
+
+		// Expect no further retry
+		mockMessaging.EXPECT().Retry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		// Expect the order to be marked failed so it can be replayed
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-1", orderRequestQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusPending}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: "failed"}).
+			Return(entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "failed"}, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusPending, NewStatus: entities.OrderStatusFailed, Reason: "Product service unavailable", Actor: "system"}).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.failed", event.RoutingKey)
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		s.processMessage(context.Background(), d)
+
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+	})
+
+	t.Run("should fail the order when product-service returns invalid data", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(pendingOrder, nil)
+		mockProductClient.EXPECT().GetProducts(gomock.Any(), []uint{123}).Return(nil, fmt.Errorf("%w: unexpected EOF", clients.ErrInvalidProductResponse))
+
+		// Expect the order to be marked failed instead of parking the message
+		mockMessaging.EXPECT().Retry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-1", orderRequestQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusPending}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: "failed"}).
+			Return(entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "failed"}, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusPending, NewStatus: entities.OrderStatusFailed, Reason: "Invalid product data", Actor: "system"}).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.failed", event.RoutingKey)
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		s.processMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-1", Body: body})
+
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+	})
+
+	t.Run("should fail the order when the database keeps failing after retries are exhausted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+		d := messaging.Message{
+			Acknowledger: ack,
+			ID:           "msg-1",
+			Headers:      map[string]interface{}{messaging.RetryCountHeader: int32(len(messaging.RetryDelays))},
+			Body:         body,
+		}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-1").Return(false, nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(entities.Order{}, errors.New("connection reset"))
+
+		// Expect the order to be reloaded and marked failed instead of parking the message
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(pendingOrder, nil)
+		mockMessaging.EXPECT().Retry(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-1", orderRequestQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusPending}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: "failed"}).
+			Return(entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: "failed"}, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		s.processMessage(context.Background(), d)
+
+		assert.True(t, ack.acked)
+		assert.False(t, ack.nacked)
+	})
+}
+
+func TestOrderService_ProcessFailedMessage(t *testing.T) {
+	body := []byte(`{"event_id":"evt-2","type":"order.failed","version":1,"occurred_at":"2025-01-02T03:04:05Z","correlation_id":"corr-1","pattern":"order.failed","data":{"orderID":10,"items":[{"productID":123,"qty":2}],"reason":"Insufficient stock"}}`)
+
+	t.Run("should persist failed order event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockFailedOrderRepo := mocks.NewMockFailedOrderRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-2").Return(false, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{ProcessedMessages: mockProcessedRepo, FailedOrders: mockFailedOrderRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-2", failedOrderQueue).Return(nil)
+		mockFailedOrderRepo.EXPECT().Create(gomock.Any(), entities.FailedOrder{
+			OrderID:  10,
+			Reason:   "Insufficient stock",
+			Payload:  string(body),
+			FailedAt: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		}).Return(entities.FailedOrder{ID: 1}, nil)
+
+		s.processFailedMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-2", Body: body})
+
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("should decode protobuf events and store them as JSON", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockFailedOrderRepo := mocks.NewMockFailedOrderRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		t.Setenv("EVENT_CONTENT_TYPE", events.ContentTypeProtobuf)
+		env, _ := events.New(events.TypeOrderFailed, events.OrderFailed{OrderID: 10, Items: []events.OrderItem{{ProductID: 123, Qty: 2}}, Reason: "Insufficient stock"}, "corr-1")
+		contentType, protoBody, err := events.Marshal(env)
+		assert.NoError(t, err)
+		assert.Equal(t, events.ContentTypeProtobuf, contentType)
+
+		ack := &fakeAcknowledger{}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-2").Return(false, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{ProcessedMessages: mockProcessedRepo, FailedOrders: mockFailedOrderRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-2", failedOrderQueue).Return(nil)
+		mockFailedOrderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, failedOrder entities.FailedOrder) (entities.FailedOrder, error) {
+				assert.Equal(t, uint(10), failedOrder.OrderID)
+				assert.Equal(t, "Insufficient stock", failedOrder.Reason)
+				assert.Equal(t, `{"orderID":10,"items":[{"productID":123,"qty":2}],"reason":"Insufficient stock"}`, eventData(t, events.TypeOrderFailed, []byte(failedOrder.Payload)))
+				return entities.FailedOrder{ID: 1}, nil
+			},
+		)
+
+		s.processFailedMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-2", ContentType: contentType, Body: protoBody})
+
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("should park undecodable message in DLQ", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+
+		s.processFailedMessage(context.Background(), messaging.Message{Acknowledger: ack, ID: "msg-2", Body: []byte("not-json")})
+
+		assert.True(t, ack.nacked)
+		assert.False(t, ack.requeue)
+	})
+}
+
+func TestOrderService_ProcessStockReply(t *testing.T) {
+	items := []entities.OrderItem{{ProductID: 123, Qty: 2}, {ProductID: 456, Qty: 1}}
+	awaitingOrder := entities.Order{ID: 10, Items: items, Status: entities.OrderStatusAwaitingStock, TotalPrice: 3500}
+
+	t.Run("should complete the order and publish order.created per item when stock is reserved", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+		completedOrder := entities.Order{ID: 10, Items: items, Status: entities.OrderStatusCompleted, TotalPrice: 3500}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-3").Return(false, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-3", stockReservedQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(awaitingOrder, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: entities.OrderStatusCompleted, TotalPrice: 3500}).Return(completedOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusAwaitingStock, NewStatus: entities.OrderStatusCompleted, Reason: "Stock reserved", Actor: "system"}).Return(nil)
+
+		// Expect one order.created event per item, keyed by product and marked as
+		// already reserved so product-service does not take the stock again
+		var payloads, keys []string
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.created", event.RoutingKey)
+			payloads = append(payloads, eventData(t, events.TypeOrderCreated, event.Payload))
+			keys = append(keys, event.PartitionKey)
+			return nil
+		}).Times(2)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123", "orders:productid:456").Return(nil)
+
+		s.processStockReply(context.Background(), stockReservedQueue, messaging.Message{
+			Acknowledger: ack,
+			ID:           "msg-3",
+			Body:         []byte(`{"pattern":"stock.reserved","data":{"orderID":10}}`),
+		})
+
+		assert.True(t, ack.acked)
+		assert.JSONEq(t, `{"orderID":10,"productID":123,"qty":2,"stockReserved":true}`, payloads[0])
+		assert.JSONEq(t, `{"orderID":10,"productID":456,"qty":1,"stockReserved":true}`, payloads[1])
+		assert.Equal(t, []string{"123", "456"}, keys)
+	})
+
+	t.Run("should fail the order when stock is rejected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-4").Return(false, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-4", stockRejectedQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(awaitingOrder, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: entities.OrderStatusFailed}).
+			Return(entities.Order{ID: 10, Items: items, Status: entities.OrderStatusFailed}, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusAwaitingStock, NewStatus: entities.OrderStatusFailed, Reason: "Insufficient stock for product 456", Actor: "system"}).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.failed", event.RoutingKey)
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123", "orders:productid:456").Return(nil)
+
+		s.processStockReply(context.Background(), stockRejectedQueue, messaging.Message{
+			Acknowledger: ack,
+			ID:           "msg-4",
+			Body:         []byte(`{"pattern":"stock.rejected","data":{"orderID":10,"reason":"Insufficient stock for product 456"}}`),
+		})
+
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("should release stock reserved for an order that already timed out", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+
+		mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-5").Return(false, nil)
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-5", stockReservedQueue).Return(nil)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: items, Status: entities.OrderStatusFailed}, nil)
+		mockHistoryRepo.EXPECT().FindByOrderID(gomock.Any(), uint(10)).Return([]entities.OrderStatusHistory{
+			{OrderID: 10, OldStatus: entities.OrderStatusPending, NewStatus: entities.OrderStatusAwaitingStock},
+			{OrderID: 10, OldStatus: entities.OrderStatusAwaitingStock, NewStatus: entities.OrderStatusFailed},
+		}, nil)
+
+		// Expect the order to stay failed and the reservation to be released
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "stock.release", event.RoutingKey)
+			assert.JSONEq(t, `{"orderID":10,"items":[{"productID":123,"qty":2},{"productID":456,"qty":1}]}`, eventData(t, events.TypeStockRelease, event.Payload))
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), gomock.Any()).Times(0)
+
+		s.processStockReply(context.Background(), stockReservedQueue, messaging.Message{
+			Acknowledger: ack,
+			ID:           "msg-5",
+			Body:         []byte(`{"pattern":"stock.reserved","data":{"orderID":10}}`),
+		})
+
+		assert.True(t, ack.acked)
+	})
+
+	keptReservations := []struct {
+		name    string
+		order   entities.Order
+		history []entities.OrderStatusHistory
+	}{
+		{
+			"a completed order",
+			entities.Order{ID: 10, Items: items, Status: entities.OrderStatusCompleted},
+			nil,
+		},
+		{
+			"an order cancelled after it completed",
+			entities.Order{ID: 10, Items: items, Status: entities.OrderStatusCancelled},
+			[]entities.OrderStatusHistory{
+				{OrderID: 10, OldStatus: entities.OrderStatusAwaitingStock, NewStatus: entities.OrderStatusCompleted},
+				{OrderID: 10, OldStatus: entities.OrderStatusCompleted, NewStatus: entities.OrderStatusCancelled},
+			},
+		},
+	}
+
+	for _, tc := range keptReservations {
+		t.Run("should not release stock on a repeated stock.reserved for "+tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockOrderRepository(ctrl)
+			mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+			mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+			mockTransactor := mocks.NewMockTransactor(ctrl)
+			mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+			mockProductClient := mocks.NewMockProductClient(ctrl)
+			mockMessaging := mocks.NewMockMessagingService(ctrl)
+			mockCache := mocks.NewMockCacheService(ctrl)
+			s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+			ack := &fakeAcknowledger{}
+
+			mockProcessedRepo.EXPECT().Exists(gomock.Any(), "msg-6").Return(false, nil)
+			mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+					return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, ProcessedMessages: mockProcessedRepo, StatusHistory: mockHistoryRepo})
+				},
+			)
+			mockProcessedRepo.EXPECT().Create(gomock.Any(), "msg-6", stockReservedQueue).Return(nil)
+			mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(tc.order, nil)
+			mockHistoryRepo.EXPECT().FindByOrderID(gomock.Any(), uint(10)).Return(tc.history, nil).AnyTimes()
+
+			// Expect the stock taken by the completed order to stay taken
+			mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+			mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+			s.processStockReply(context.Background(), stockReservedQueue, messaging.Message{
+				Acknowledger: ack,
+				ID:           "msg-6",
+				Body:         []byte(`{"pattern":"stock.reserved","data":{"orderID":10}}`),
+			})
+
+			assert.True(t, ack.acked)
+		})
+	}
+
+	t.Run("should park reply without order ID in DLQ", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache).(*orderService)
+
+		ack := &fakeAcknowledger{}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+
+		s.processStockReply(context.Background(), stockReservedQueue, messaging.Message{Acknowledger: ack, ID: "msg-6", Body: []byte(`{"pattern":"stock.reserved","data":{}}`)})
+
+		assert.True(t, ack.nacked)
+		assert.False(t, ack.requeue)
+	})
+}
+
+func TestOrderService_StartOrderConsumer(t *testing.T) {
+	t.Run("should return once the context is cancelled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		msgs := make(chan messaging.Message)
+		mockMessaging.EXPECT().Consume(gomock.Any(), orderRequestQueue, "order.created.request").Return((<-chan messaging.Message)(msgs), nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			s.StartOrderConsumer(ctx)
+			close(done)
+		}()
+
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("consumer did not stop after context cancellation")
+		}
+	})
+}
+
+func TestOrderService_Update(t *testing.T) {
+	t.Run("should update order and record history when transition is allowed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		updatedOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123}}, Status: entities.OrderStatusRefunded}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123}}, Status: entities.OrderStatusCompleted}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: entities.OrderStatusRefunded}).Return(updatedOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{
+			OrderID:   10,
+			OldStatus: entities.OrderStatusCompleted,
+			NewStatus: entities.OrderStatusRefunded,
+			Reason:    "Customer returned item",
+			Actor:     "support-agent",
+		}).Return(nil)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		ctx := helpers.WithActor(context.Background(), "support-agent")
+		result, err := s.Update(ctx, entities.Order{ID: 10, Status: entities.OrderStatusRefunded}, "Customer returned item")
+
+		assert.NoError(t, err)
+		assert.Equal(t, updatedOrder, result)
+	})
+
+	t.Run("should reject illegal transition", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusCompleted}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+		result, err := s.Update(context.Background(), entities.Order{ID: 10, Status: entities.OrderStatusPending}, "")
+
+		assert.Equal(t, entities.Order{}, result)
+		assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	})
+
+	sideEffectCases := []struct {
+		name string
+		from entities.OrderStatus
+		to   entities.OrderStatus
+	}{
+		{"completed to cancelled skips the restock event", entities.OrderStatusCompleted, entities.OrderStatusCancelled},
+		{"awaiting_stock to completed skips order.created", entities.OrderStatusAwaitingStock, entities.OrderStatusCompleted},
+		{"awaiting_stock to cancelled skips stock.release", entities.OrderStatusAwaitingStock, entities.OrderStatusCancelled},
+		{"failed to pending skips the new order request", entities.OrderStatusFailed, entities.OrderStatusPending},
+		{"pending to processing strands the order", entities.OrderStatusPending, entities.OrderStatusProcessing},
+	}
+
+	for _, tc := range sideEffectCases {
+		t.Run("should reject "+tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockOrderRepository(ctrl)
+			mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+			mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+			mockTransactor := mocks.NewMockTransactor(ctrl)
+			mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+			mockProductClient := mocks.NewMockProductClient(ctrl)
+			mockMessaging := mocks.NewMockMessagingService(ctrl)
+			mockCache := mocks.NewMockCacheService(ctrl)
+			s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+			mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+					return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+				},
+			)
+			mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: tc.from}, nil)
+
+			// Expect the status to stay untouched since PUT cannot emit the events
+			mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+			mockHistoryRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+			mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+			result, err := s.Update(context.Background(), entities.Order{ID: 10, Status: tc.to}, "")
+
+			assert.Equal(t, entities.Order{}, result)
+			assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+		})
+	}
+
+	t.Run("should publish order.failed when an order is failed by request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		items := []entities.OrderItem{{ProductID: 123, Qty: 2}}
+		failedOrder := entities.Order{ID: 10, Items: items, Status: entities.OrderStatusFailed}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: items, Status: entities.OrderStatusProcessing}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: entities.OrderStatusFailed}).Return(failedOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{
+			OrderID:   10,
+			OldStatus: entities.OrderStatusProcessing,
+			NewStatus: entities.OrderStatusFailed,
+			Reason:    "Failed by request",
+			Actor:     "system",
+		}).Return(nil)
+
+		// Expect order.failed so the order lands in failed_orders and can be replayed
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.failed", event.RoutingKey)
+			assert.JSONEq(t, `{"orderID":10,"items":[{"productID":123,"qty":2}],"reason":"Failed by request"}`, eventData(t, events.TypeOrderFailed, event.Payload))
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		result, err := s.Update(context.Background(), entities.Order{ID: 10, Status: entities.OrderStatusFailed}, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, failedOrder, result)
+	})
+
+	t.Run("should reject unknown status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.Update(context.Background(), entities.Order{ID: 10, Status: "compleetd"}, "")
+
+		assert.ErrorIs(t, err, ErrInvalidOrderStatus)
+	})
+}
+
+func TestOrderService_Delete(t *testing.T) {
+	t.Run("should soft delete a settled order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123}}, Status: entities.OrderStatusCompleted}, nil)
+		mockRepo.EXPECT().Delete(gomock.Any(), uint(10)).Return(nil)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		err := s.Delete(context.Background(), 10)
+
+		assert.NoError(t, err)
+	})
+
+	for _, status := range []entities.OrderStatus{entities.OrderStatusPending, entities.OrderStatusProcessing, entities.OrderStatusAwaitingStock} {
+		t.Run("should reject deleting a "+string(status)+" order", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockOrderRepository(ctrl)
+			mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+			mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+			mockTransactor := mocks.NewMockTransactor(ctrl)
+			mockProductClient := mocks.NewMockProductClient(ctrl)
+			mockMessaging := mocks.NewMockMessagingService(ctrl)
+			mockCache := mocks.NewMockCacheService(ctrl)
+			s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+			mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+					return fn(repositories.Repositories{Orders: mockRepo})
+				},
+			)
+			mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: status}, nil)
+
+			// Expect the order to stay visible to the consumers and the sweeper
+			mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+			mockCache.EXPECT().Del(gomock.Any(), gomock.Any()).Times(0)
+
+			err := s.Delete(context.Background(), 10)
+
+			assert.ErrorIs(t, err, ErrOrderNotDeletable)
+		})
+	}
+}
+
+func TestOrderService_Cancel(t *testing.T) {
+	t.Run("should cancel completed order and publish restock event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		cancelledOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: entities.OrderStatusCancelled}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: entities.OrderStatusCompleted}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: entities.OrderStatusCancelled}).Return(cancelledOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusCompleted, NewStatus: entities.OrderStatusCancelled, Reason: "Cancelled by request", Actor: "system"}).Return(nil)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			assert.Equal(t, "order.cancelled", event.RoutingKey)
+			assert.JSONEq(t, `{"orderID":10,"productID":123,"qty":2,"restock":true}`, eventData(t, events.TypeOrderCancelled, event.Payload))
+			return nil
+		})
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		result, err := s.Cancel(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, cancelledOrder, result)
+	})
+
+	t.Run("should release reserved stock when cancelling an order awaiting stock", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		cancelledOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: entities.OrderStatusCancelled}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123, Qty: 2}}, Status: entities.OrderStatusAwaitingStock}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: entities.OrderStatusCancelled}).Return(cancelledOrder, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+		var routingKeys []string
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			routingKeys = append(routingKeys, event.RoutingKey)
+			return nil
+		}).Times(2)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		_, err := s.Cancel(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"order.cancelled", "stock.release"}, routingKeys)
+	})
+
+	t.Run("should reject cancelling an already cancelled order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().FindByIDForUpdate(gomock.Any(), uint(10)).Return(entities.Order{ID: 10, Status: entities.OrderStatusCancelled}, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Times(0)
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+		result, err := s.Cancel(context.Background(), 10)
+
+		assert.Equal(t, entities.Order{}, result)
+		assert.ErrorIs(t, err, ErrOrderNotCancellable)
+	})
+}
+
+func TestOrderService_FindHistory(t *testing.T) {
+	t.Run("should return status changes of an existing order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		history := []entities.OrderStatusHistory{
+			{ID: 1, OrderID: 10, NewStatus: entities.OrderStatusPending, Actor: "api"},
+			{ID: 2, OrderID: 10, OldStatus: entities.OrderStatusPending, NewStatus: entities.OrderStatusCompleted, Actor: "system"},
+		}
+
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(entities.Order{ID: 10}, nil)
+		mockHistoryRepo.EXPECT().FindByOrderID(gomock.Any(), uint(10)).Return(history, nil)
+
+		result, err := s.FindHistory(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, history, result)
+	})
+
+	t.Run("should return not found for unknown order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(entities.Order{}, gorm.ErrRecordNotFound)
+		mockHistoryRepo.EXPECT().FindByOrderID(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.FindHistory(context.Background(), 10)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestOrderService_Restore(t *testing.T) {
+	t.Run("should restore deleted order and invalidate cache", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		restoredOrder := entities.Order{ID: 10, Items: []entities.OrderItem{{ProductID: 123}}, Status: entities.OrderStatusCompleted}
+
+		mockRepo.EXPECT().Restore(gomock.Any(), uint(10)).Return(nil)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(restoredOrder, nil)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		result, err := s.Restore(context.Background(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, restoredOrder, result)
+	})
+
+	t.Run("should return conflict when order is not deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		mockRepo.EXPECT().Restore(gomock.Any(), uint(10)).Return(gorm.ErrRecordNotFound)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(entities.Order{ID: 10}, nil)
+		mockCache.EXPECT().Del(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.Restore(context.Background(), 10)
+
+		assert.ErrorIs(t, err, ErrOrderNotDeleted)
+	})
+
+	t.Run("should return not found for unknown order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockProcessedRepo := mocks.NewMockProcessedMessageRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockProductClient := mocks.NewMockProductClient(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		s := NewOrderService(mockRepo, mockProcessedRepo, mockHistoryRepo, mockTransactor, mockProductClient, mockMessaging, mockCache)
+
+		mockRepo.EXPECT().Restore(gomock.Any(), uint(10)).Return(gorm.ErrRecordNotFound)
+		mockRepo.EXPECT().FindByID(gomock.Any(), uint(10)).Return(entities.Order{}, gorm.ErrRecordNotFound)
+
+		_, err := s.Restore(context.Background(), 10)
+
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,7 @@
+package services
+
+import "context"
+
+type OutboxRelay interface {
+	Start(ctx context.Context)
+}
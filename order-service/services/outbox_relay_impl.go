@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"log"
+	"order-service/entities"
+	"order-service/messaging"
+	"order-service/repositories"
+	"sync"
+	"time"
+)
+
+const (
+	outboxPollInterval = 200 * time.Millisecond
+	outboxBatchSize    = 100
+	outboxMaxBackoff   = time.Minute
+	outboxFlushTimeout = 5 * time.Second
+)
+
+type outboxRelay struct {
+	transactor repositories.Transactor
+	messaging  messaging.MessagingService
+}
+
+func NewOutboxRelay(
+	transactor repositories.Transactor,
+	messaging messaging.MessagingService,
+) OutboxRelay {
+	return &outboxRelay{
+		transactor: transactor,
+		messaging:  messaging,
+	}
+}
+
+// Start menjalankan relay sampai ctx dibatalkan, lalu mengirim sisa event
+// pending sekali lagi sebelum berhenti.
+func (r *outboxRelay) Start(ctx context.Context) {
+	log.Println("Outbox relay started, polling for pending events...")
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopping, flushing pending events...")
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), outboxFlushTimeout)
+			r.drain(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+		}
+	}
+}
+
+func (r *outboxRelay) drain(ctx context.Context) {
+	for {
+		sent, err := r.relayBatch(ctx)
+		if err != nil {
+			log.Printf("Failed to relay outbox events: %v", err)
+			return
+		}
+
+		if sent < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// relayBatch mengirim satu batch event pending dan mengembalikan jumlah
+// event yang diproses. Event yang gagal dikirim dijadwalkan ulang dengan
+// exponential backoff.
+func (r *outboxRelay) relayBatch(ctx context.Context) (int, error) {
+	var processed int
+
+	err := r.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		events, err := repos.Outbox.FindPending(ctx, outboxBatchSize)
+		if err != nil {
+			return err
+		}
+
+		// Keys are published concurrently so each pooled channel waits for its
+		// own confirm, but events sharing a key go out one by one in order
+		publishErrs := make([]error, len(events))
+		published := make([]bool, len(events))
+		var wg sync.WaitGroup
+		for _, sequence := range outboxSequences(events) {
+			wg.Add(1)
+			go func(sequence []int) {
+				defer wg.Done()
+				for _, i := range sequence {
+					event := events[i]
+					publishErrs[i] = r.messaging.PublishEvent(ctx, event.Exchange, event.RoutingKey, event.PartitionKey, event.ID, event.ContentType, event.Payload)
+					published[i] = true
+					if publishErrs[i] != nil {
+						return // Stop the key so later events cannot overtake the failed one
+					}
+				}
+			}(sequence)
+		}
+		wg.Wait()
+
+		for i, event := range events {
+			if !published[i] {
+				continue // Left pending until the earlier event with the same key is sent
+			}
+
+			if publishErr := publishErrs[i]; publishErr != nil {
+				log.Printf("Failed to publish outbox event %s (%s): %v", event.ID, event.RoutingKey, publishErr)
+
+				attempts := event.Attempts + 1
+				if err := repos.Outbox.MarkFailed(ctx, event.ID, attempts, time.Now().Add(outboxBackoff(attempts)), publishErr.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := repos.Outbox.MarkSent(ctx, event.ID); err != nil {
+				return err
+			}
+		}
+
+		processed = len(events)
+		return nil
+	})
+
+	return processed, err
+}
+
+// outboxSequences mengelompokkan indeks event per routing key dan partition
+// key dengan urutan yang sama seperti di batch. Routing key ikut dipakai karena
+// ID order dan ID produk bisa bernilai sama tetapi dikirim ke topic berbeda.
+// Event tanpa key masing-masing menjadi satu kelompok karena urutannya tidak
+// perlu dijaga.
+func outboxSequences(events []entities.OutboxEvent) [][]int {
+	type sequenceKey struct{ routingKey, partitionKey string }
+
+	var sequences [][]int
+	byKey := make(map[sequenceKey]int)
+
+	for i, event := range events {
+		if event.PartitionKey == "" {
+			sequences = append(sequences, []int{i})
+			continue
+		}
+
+		key := sequenceKey{event.RoutingKey, event.PartitionKey}
+		n, ok := byKey[key]
+		if !ok {
+			n = len(sequences)
+			byKey[key] = n
+			sequences = append(sequences, nil)
+		}
+		sequences[n] = append(sequences[n], i)
+	}
+
+	return sequences
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	backoff := time.Second << (attempts - 1)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+
+	return backoff
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"order-service/entities"
+	"order-service/mocks"
+	"order-service/repositories"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOutboxRelay_RelayBatch(t *testing.T) {
+	events := []entities.OutboxEvent{
+		{ID: "event-1", Exchange: "order_exchange", RoutingKey: "order.created", PartitionKey: "123", ContentType: "application/json", Payload: []byte(`{"a":1}`), Status: "pending"},
+		{ID: "event-2", Exchange: "order_exchange", RoutingKey: "order.failed", PartitionKey: "10", ContentType: "application/x-protobuf", Payload: []byte(`{"b":2}`), Status: "pending", Attempts: 2},
+	}
+
+	t.Run("should mark published events as sent and reschedule failed ones", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		r := NewOutboxRelay(mockTransactor, mockMessaging).(*outboxRelay)
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Outbox: mockOutbox})
+			},
+		)
+		mockOutbox.EXPECT().FindPending(gomock.Any(), outboxBatchSize).Return(events, nil)
+
+		// Expect first event published and marked as sent
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), "order_exchange", "order.created", "123", "event-1", "application/json", []byte(`{"a":1}`)).Return(nil)
+		mockOutbox.EXPECT().MarkSent(gomock.Any(), "event-1").Return(nil)
+
+		// Expect second event rescheduled with incremented attempts
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), "order_exchange", "order.failed", "10", "event-2", "application/x-protobuf", []byte(`{"b":2}`)).Return(errors.New("broker down"))
+		mockOutbox.EXPECT().MarkFailed(gomock.Any(), "event-2", 3, gomock.Any(), "broker down").DoAndReturn(
+			func(_ context.Context, id string, attempts int, nextAttemptAt time.Time, lastError string) error {
+				assert.True(t, nextAttemptAt.After(time.Now()))
+				return nil
+			},
+		)
+
+		processed, err := r.relayBatch(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, processed)
+	})
+
+	t.Run("should publish events with the same partition key in order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		r := NewOutboxRelay(mockTransactor, mockMessaging).(*outboxRelay)
+
+		keyed := []entities.OutboxEvent{
+			{ID: "event-1", RoutingKey: "order.created", PartitionKey: "123"},
+			{ID: "event-2", RoutingKey: "order.created", PartitionKey: "456"},
+			{ID: "event-3", RoutingKey: "order.created", PartitionKey: "123"},
+			{ID: "event-4", RoutingKey: "order.created", PartitionKey: "123"},
+			{ID: "event-5", RoutingKey: "order.created", PartitionKey: "456"},
+		}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Outbox: mockOutbox})
+			},
+		)
+		mockOutbox.EXPECT().FindPending(gomock.Any(), outboxBatchSize).Return(keyed, nil)
+
+		var mu sync.Mutex
+		published := map[string][]string{}
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, _, partitionKey, messageID, _ string, _ []byte) error {
+				// Slow down the first event of each key so a later one would overtake it if sent concurrently
+				if messageID == "event-1" || messageID == "event-2" {
+					time.Sleep(20 * time.Millisecond)
+				}
+
+				mu.Lock()
+				published[partitionKey] = append(published[partitionKey], messageID)
+				mu.Unlock()
+				return nil
+			},
+		).Times(len(keyed))
+		mockOutbox.EXPECT().MarkSent(gomock.Any(), gomock.Any()).Return(nil).Times(len(keyed))
+
+		processed, err := r.relayBatch(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, len(keyed), processed)
+		assert.Equal(t, []string{"event-1", "event-3", "event-4"}, published["123"])
+		assert.Equal(t, []string{"event-2", "event-5"}, published["456"])
+	})
+
+	t.Run("should stop a partition key at its first failed event", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		r := NewOutboxRelay(mockTransactor, mockMessaging).(*outboxRelay)
+
+		keyed := []entities.OutboxEvent{
+			{ID: "event-1", RoutingKey: "order.created", PartitionKey: "123"},
+			{ID: "event-2", RoutingKey: "order.created", PartitionKey: "456"},
+			{ID: "event-3", RoutingKey: "order.created", PartitionKey: "123"},
+			{ID: "event-4", RoutingKey: "stock.reserve", PartitionKey: "123"},
+		}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Outbox: mockOutbox})
+			},
+		)
+		mockOutbox.EXPECT().FindPending(gomock.Any(), outboxBatchSize).Return(keyed, nil)
+
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), "order.created", "123", "event-1", gomock.Any(), gomock.Any()).Return(errors.New("broker down"))
+		mockOutbox.EXPECT().MarkFailed(gomock.Any(), "event-1", 1, gomock.Any(), "broker down").Return(nil)
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), "order.created", "456", "event-2", gomock.Any(), gomock.Any()).Return(nil)
+		mockOutbox.EXPECT().MarkSent(gomock.Any(), "event-2").Return(nil)
+
+		// Expect the same key on another routing key to go out, since it is another topic
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), "stock.reserve", "123", "event-4", gomock.Any(), gomock.Any()).Return(nil)
+		mockOutbox.EXPECT().MarkSent(gomock.Any(), "event-4").Return(nil)
+
+		// Expect the later event with the failed key to stay pending untouched
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "event-3", gomock.Any(), gomock.Any()).Times(0)
+		mockOutbox.EXPECT().MarkFailed(gomock.Any(), "event-3", gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		mockOutbox.EXPECT().MarkSent(gomock.Any(), "event-3").Times(0)
+
+		_, err := r.relayBatch(context.Background())
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return error when pending events cannot be loaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		r := NewOutboxRelay(mockTransactor, mockMessaging).(*outboxRelay)
+
+		expectedErr := errors.New("db connection error")
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Outbox: mockOutbox})
+			},
+		)
+		mockOutbox.EXPECT().FindPending(gomock.Any(), outboxBatchSize).Return(nil, expectedErr)
+
+		// Expect nothing published
+		mockMessaging.EXPECT().PublishEvent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		processed, err := r.relayBatch(context.Background())
+
+		assert.Equal(t, 0, processed)
+		assert.EqualError(t, err, expectedErr.Error())
+	})
+}
@@ -0,0 +1,7 @@
+package services
+
+import "context"
+
+type ProductCatalogService interface {
+	StartProductEventConsumer(ctx context.Context)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"log"
+	"order-service/clients"
+	"order-service/events"
+	"order-service/messaging"
+	"os"
+)
+
+const productEventQueue = "order-service.product.events"
+
+type productCatalogService struct {
+	catalog   clients.ProductCatalog
+	messaging messaging.MessagingService
+	exchange  string
+}
+
+func NewProductCatalogService(catalog clients.ProductCatalog, messaging messaging.MessagingService) ProductCatalogService {
+	return &productCatalogService{
+		catalog:   catalog,
+		messaging: messaging,
+		exchange:  os.Getenv("RABBITMQ_PRODUCT_EXCHANGE_NAME"),
+	}
+}
+
+// StartProductEventConsumer memperbarui katalog produk dari event
+// product-service sampai ctx dibatalkan.
+func (s *productCatalogService) StartProductEventConsumer(ctx context.Context) {
+	msgs, err := s.messaging.ConsumeExchange(ctx, s.exchange, productEventQueue, "product.created", "product.updated", "product.deleted")
+	if err != nil {
+		log.Fatalf("Failed to register product event consumer: %v", err)
+	}
+
+	log.Println("Product event consumer started, waiting for messages...")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Product event consumer stopped")
+			return
+		case d, ok := <-msgs:
+			if !ok {
+				return
+			}
+			s.processProductEvent(ctx, d)
+		}
+	}
+}
+
+// processProductEvent menerapkan satu event produk ke katalog. Urutan event
+// dijaga oleh version di katalog, sehingga redelivery aman diproses ulang.
+func (s *productCatalogService) processProductEvent(ctx context.Context, d messaging.Message) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), messageTimeout)
+	defer cancel()
+
+	env, err := events.DecodeEnvelope(d.ContentType, d.Body)
+	if err != nil {
+		log.Printf("Failed to decode product event: %v", err)
+		d.Nack(false) // Park invalid message in DLQ
+		return
+	}
+
+	var data events.Product
+	if err := env.DecodeData(&data); err != nil {
+		log.Printf("Failed to decode product event: %v", err)
+		d.Nack(false) // Park invalid message in DLQ
+		return
+	}
+
+	product := clients.Product{
+		ID:      data.ID,
+		Name:    data.Name,
+		Price:   data.Price,
+		Qty:     data.Qty,
+		Version: data.Version,
+	}
+
+	switch env.Type {
+	case events.TypeProductCreated, events.TypeProductUpdated:
+		err = s.catalog.Save(ctx, product)
+	case events.TypeProductDeleted:
+		err = s.catalog.Remove(ctx, product.ID, product.Version)
+	default:
+		log.Printf("Unexpected event %s on product queue", env.Type)
+		d.Nack(false) // Park in DLQ
+		return
+	}
+
+	if err != nil {
+		log.Printf("Failed to apply %s for product ID %d: %v", env.Type, product.ID, err)
+		retryDelivery(ctx, s.messaging, productEventQueue, d)
+		return
+	}
+
+	d.Ack()
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"order-service/clients"
+	"order-service/messaging"
+	"order-service/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProductCatalogService_ProcessProductEvent(t *testing.T) {
+	t.Run("should save created and updated products", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCatalog := mocks.NewMockProductCatalog(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		s := NewProductCatalogService(mockCatalog, mockMessaging).(*productCatalogService)
+
+		ack := &fakeAcknowledger{}
+
+		mockCatalog.EXPECT().Save(gomock.Any(), clients.Product{ID: 1, Name: "Keyboard", Price: 1500, Qty: 10, Version: 4}).Return(nil)
+
+		s.processProductEvent(context.Background(), messaging.Message{
+			Acknowledger: ack,
+			Body:         []byte(`{"pattern":"product.updated","data":{"id":1,"name":"Keyboard","price":1500,"qty":10,"version":4}}`),
+		})
+
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("should remove deleted products", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCatalog := mocks.NewMockProductCatalog(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		s := NewProductCatalogService(mockCatalog, mockMessaging).(*productCatalogService)
+
+		ack := &fakeAcknowledger{}
+
+		mockCatalog.EXPECT().Remove(gomock.Any(), uint(1), int64(5)).Return(nil)
+
+		s.processProductEvent(context.Background(), messaging.Message{
+			Acknowledger: ack,
+			Body:         []byte(`{"pattern":"product.deleted","data":{"id":1,"version":5}}`),
+		})
+
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("should schedule a retry when the catalog cannot be updated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCatalog := mocks.NewMockProductCatalog(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		s := NewProductCatalogService(mockCatalog, mockMessaging).(*productCatalogService)
+
+		ack := &fakeAcknowledger{}
+
+		mockCatalog.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errors.New("redis down"))
+		mockMessaging.EXPECT().Retry(gomock.Any(), productEventQueue, gomock.Any(), 1).Return(nil)
+
+		s.processProductEvent(context.Background(), messaging.Message{
+			Acknowledger: ack,
+			Body:         []byte(`{"pattern":"product.created","data":{"id":1,"price":1500,"qty":10}}`),
+		})
+
+		assert.True(t, ack.acked)
+	})
+
+	t.Run("should park unknown events in DLQ", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockCatalog := mocks.NewMockProductCatalog(ctrl)
+		mockMessaging := mocks.NewMockMessagingService(ctrl)
+		s := NewProductCatalogService(mockCatalog, mockMessaging).(*productCatalogService)
+
+		ack := &fakeAcknowledger{}
+
+		s.processProductEvent(context.Background(), messaging.Message{
+			Acknowledger: ack,
+			Body:         []byte(`{"pattern":"product.archived","data":{"id":1}}`),
+		})
+
+		assert.True(t, ack.nacked)
+		assert.False(t, ack.requeue)
+	})
+}
+
+func TestProductCatalogService_StartProductEventConsumer(t *testing.T) {
+	t.Run("should consume product events from the in-memory broker", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		t.Setenv("RABBITMQ_PRODUCT_EXCHANGE_NAME", "product_exchange")
+
+		broker := messaging.NewMemoryService()
+		assert.NoError(t, broker.Connect())
+		defer broker.Close()
+		assert.NoError(t, broker.Bind("product_exchange", productEventQueue, "product.created"))
+
+		mockCatalog := mocks.NewMockProductCatalog(ctrl)
+		s := NewProductCatalogService(mockCatalog, broker)
+
+		saved := make(chan struct{})
+		mockCatalog.EXPECT().Save(gomock.Any(), clients.Product{ID: 1, Price: 1500, Qty: 10}).DoAndReturn(
+			func(_ context.Context, _ clients.Product) error {
+				close(saved)
+				return nil
+			},
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		assert.NoError(t, broker.PublishEvent(ctx, "product_exchange", "product.created", "", "msg-1", "", []byte(`{"pattern":"product.created","data":{"id":1,"price":1500,"qty":10}}`)))
+		assert.NoError(t, broker.PublishEvent(ctx, "product_exchange", "product.created", "", "msg-2", "", []byte(`not-json`)))
+
+		go s.StartProductEventConsumer(ctx)
+
+		// Expect the valid event applied and the invalid one parked in the DLQ
+		select {
+		case <-saved:
+		case <-time.After(time.Second):
+			t.Fatal("product event was not consumed")
+		}
+		assert.Eventually(t, func() bool {
+			return len(broker.DeadLetters(productEventQueue)) == 1
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "msg-2", broker.DeadLetters(productEventQueue)[0].ID)
+	})
+}
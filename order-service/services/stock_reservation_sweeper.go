@@ -0,0 +1,7 @@
+package services
+
+import "context"
+
+type StockReservationSweeper interface {
+	Start(ctx context.Context)
+}
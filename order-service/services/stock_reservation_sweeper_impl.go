@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"log"
+	"order-service/database"
+	"order-service/entities"
+	"order-service/events"
+	"order-service/repositories"
+	"os"
+	"time"
+)
+
+const (
+	stockSweepInterval         = 10 * time.Second
+	stockSweepBatch            = 100
+	defaultStockReserveTimeout = 2 * time.Minute
+)
+
+type stockReservationSweeper struct {
+	transactor repositories.Transactor
+	cache      database.CacheService
+	timeout    time.Duration
+	exchange   string
+}
+
+func NewStockReservationSweeper(
+	transactor repositories.Transactor,
+	cache database.CacheService,
+) StockReservationSweeper {
+	return &stockReservationSweeper{
+		transactor: transactor,
+		cache:      cache,
+		timeout:    stockReserveTimeout(),
+		exchange:   os.Getenv("RABBITMQ_EXCHANGE_NAME"),
+	}
+}
+
+// stockReserveTimeout membaca STOCK_RESERVATION_TIMEOUT (contoh: "90s"),
+// default 2 menit.
+func stockReserveTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("STOCK_RESERVATION_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return defaultStockReserveTimeout
+	}
+
+	return timeout
+}
+
+// Start memeriksa order awaiting_stock secara berkala sampai ctx dibatalkan.
+func (w *stockReservationSweeper) Start(ctx context.Context) {
+	log.Printf("Stock reservation sweeper started, timeout %s", w.timeout)
+
+	ticker := time.NewTicker(stockSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stock reservation sweeper stopped")
+			return
+		case <-ticker.C:
+			if _, err := w.sweep(ctx); err != nil {
+				log.Printf("Failed to expire stock reservations: %v", err)
+			}
+		}
+	}
+}
+
+// sweep menandai failed order yang tidak mendapat balasan stok sebelum
+// timeout, lalu mengirim stock.release untuk reservasi yang mungkin sudah
+// dibuat tetapi balasannya hilang.
+func (w *stockReservationSweeper) sweep(ctx context.Context) (int, error) {
+	var expiredOrders []entities.Order
+	err := w.transactor.WithinTransaction(ctx, func(repos repositories.Repositories) error {
+		orders, err := repos.Orders.FindStale(ctx, entities.OrderStatusAwaitingStock, time.Now().Add(-w.timeout), stockSweepBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			failedOrder, err := markOrderFailed(ctx, repos, w.exchange, order, "Stock reservation timed out")
+			if err != nil {
+				return err
+			}
+
+			if err := publishStockEvent(ctx, repos, w.exchange, events.TypeStockRelease, failedOrder); err != nil {
+				return err
+			}
+
+			expiredOrders = append(expiredOrders, failedOrder)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, order := range expiredOrders {
+		log.Printf("Stock reservation for order ID %d timed out", order.ID)
+		invalidateOrderCache(ctx, w.cache, order)
+	}
+
+	return len(expiredOrders), nil
+}
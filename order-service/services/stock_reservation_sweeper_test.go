@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"order-service/entities"
+	"order-service/mocks"
+	"order-service/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStockReservationSweeper_Sweep(t *testing.T) {
+	t.Run("should fail expired orders and release their stock", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockTransactor := mocks.NewMockTransactor(ctrl)
+		mockRepo := mocks.NewMockOrderRepository(ctrl)
+		mockHistoryRepo := mocks.NewMockOrderStatusHistoryRepository(ctrl)
+		mockOutbox := mocks.NewMockOutboxRepository(ctrl)
+		mockCache := mocks.NewMockCacheService(ctrl)
+		w := NewStockReservationSweeper(mockTransactor, mockCache).(*stockReservationSweeper)
+		w.timeout = time.Minute
+
+		items := []entities.OrderItem{{ProductID: 123, Qty: 2}}
+
+		mockTransactor.EXPECT().WithinTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(repos repositories.Repositories) error) error {
+				return fn(repositories.Repositories{Orders: mockRepo, Outbox: mockOutbox, StatusHistory: mockHistoryRepo})
+			},
+		)
+		mockRepo.EXPECT().FindStale(gomock.Any(), entities.OrderStatusAwaitingStock, gomock.Any(), stockSweepBatch).DoAndReturn(
+			func(_ context.Context, _ entities.OrderStatus, updatedBefore time.Time, _ int) ([]entities.Order, error) {
+				assert.WithinDuration(t, time.Now().Add(-time.Minute), updatedBefore, time.Second)
+				return []entities.Order{{ID: 10, Items: items, Status: entities.OrderStatusAwaitingStock}}, nil
+			},
+		)
+		mockRepo.EXPECT().Update(gomock.Any(), entities.Order{ID: 10, Status: entities.OrderStatusFailed}).
+			Return(entities.Order{ID: 10, Items: items, Status: entities.OrderStatusFailed}, nil)
+		mockHistoryRepo.EXPECT().Create(gomock.Any(), entities.OrderStatusHistory{OrderID: 10, OldStatus: entities.OrderStatusAwaitingStock, NewStatus: entities.OrderStatusFailed, Reason: "Stock reservation timed out", Actor: "system"}).Return(nil)
+
+		// Expect order.failed followed by the stock.release compensation
+		var routingKeys []string
+		mockOutbox.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event entities.OutboxEvent) error {
+			routingKeys = append(routingKeys, event.RoutingKey)
+			return nil
+		}).Times(2)
+		mockCache.EXPECT().Del(gomock.Any(), "orders:id:10", "orders:productid:123").Return(nil)
+
+		expired, err := w.sweep(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, expired)
+		assert.Equal(t, []string{"order.failed", "stock.release"}, routingKeys)
+	})
+}
@@ -27,6 +27,20 @@ async function bootstrap() {
     },
   });
 
+  app.connectMicroservice({
+    transport: Transport.RMQ,
+    options: {
+      urls: [RABBITMQ_URL],
+      queue: 'product-service.order.cancelled',
+      queueOptions: { durable: true, autoDelete: false },
+      noAck: false,
+      exchange: RABBITMQ_ORDER_EXCHANGE_NAME,
+      exchangeType: 'direct',
+      routingKey: 'order.cancelled',
+      prefetchCount: 10,
+    },
+  });
+
   app.useGlobalPipes(new ValidationPipe({
     whitelist: true,
     forbidNonWhitelisted: true,
@@ -61,4 +61,33 @@ export class ProductsController {
             channel.nack(originalMsg, false, true);
         }
     }
+
+    @EventPattern('order.cancelled')
+    async handleOrderCancelled(
+        @Payload() data: any,
+        @Ctx() context: RmqContext,
+    ) {
+        console.log('[Event] order.cancelled received:', data);
+
+        try {
+            if (data.restock) {
+                await this.productsService.restoreStock(
+                    data.orderID,
+                    data.productID,
+                    data.qty,
+                );
+            }
+
+            const channel = context.getChannelRef();
+            const originalMsg = context.getMessage();
+            channel.ack(originalMsg);
+
+        } catch (err) {
+            console.error('Error processing order.cancelled:', err);
+
+            const channel = context.getChannelRef();
+            const originalMsg = context.getMessage();
+            channel.nack(originalMsg, false, true);
+        }
+    }
 }
\ No newline at end of file
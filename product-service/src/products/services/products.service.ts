@@ -99,4 +99,23 @@ export class ProductsService {
             await this.client.del(`products:id:${product.id}`);
         });
     }
+
+    async restoreStock(orderId: number, productId: number, qty: number): Promise<void> {
+        await this.dataSource.transaction(async manager => {
+            const product = await manager.getRepository(Product)
+                .createQueryBuilder('product')
+                .setLock('pessimistic_write')
+                .where('product.id = :id', { id: productId })
+                .getOne();
+
+            if (!product) {
+                return;
+            }
+
+            product.qty = product.qty + qty;
+            await manager.save(product);
+
+            await this.client.del(`products:id:${product.id}`);
+        });
+    }
 }
\ No newline at end of file
@@ -18,6 +18,8 @@ export class RabbitmqInitService implements OnModuleInit {
         await channel.assertExchange(ORDER_EXCHANGE, 'direct', { durable: true });
         await channel.assertQueue('product-service.order.created', { durable: true });
         await channel.bindQueue('product-service.order.created', ORDER_EXCHANGE, 'order.created');
+        await channel.assertQueue('product-service.order.cancelled', { durable: true });
+        await channel.bindQueue('product-service.order.cancelled', ORDER_EXCHANGE, 'order.cancelled');
 
         await channel.close();
         await connection.close();